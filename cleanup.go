@@ -0,0 +1,101 @@
+package debefix
+
+import (
+	"context"
+	"strings"
+)
+
+// Cleanup calls the callback for each row of the resolved data with a ResolveTypeDelete resolve info, so the rows
+// can be deleted. Tables are walked in the reverse of ResolvedData.TableOrder, and rows in the reverse of the order
+// they were added, so rows are always deleted before the rows they depend on.
+// The resolve info UpdateKeyFields contains the table's key fields, and the values are the resolved row values,
+// including any changes done by updates.
+//
+// All tables with rows to delete must have key fields, set using Data.SetTableKeyFields or from the primary key of
+// the table schema (Data.SetTableSchema). If any of them don't, an error is returned before any process or callback
+// is called.
+//
+// If the resolved data was resolved on top of a base data using WithResolveOptionBase, the rows copied from the base
+// data are not deleted.
+//
+// Rows which are part of a circular dependency may need to have the fields set by updates cleared before being
+// deleted.
+func Cleanup(ctx context.Context, resolvedData *ResolvedData, callback ResolveCallback, options ...CleanupOption) error {
+	var optns cleanupOptions
+	for _, opt := range options {
+		opt(&optns)
+	}
+
+	err := checkCleanupKeyFields(resolvedData)
+	if err != nil {
+		return err
+	}
+
+	return runProcesses(ctx, optns.processes, func(ctx context.Context) error {
+		return cleanup(ctx, resolvedData, callback)
+	})
+}
+
+// checkCleanupKeyFields returns an error if any table with rows to delete has no key fields.
+func checkCleanupKeyFields(resolvedData *ResolvedData) error {
+	var missing []string
+	for _, tableID := range resolvedData.TableOrder {
+		table, ok := resolvedData.Tables[tableID]
+		if !ok || len(table.KeyFields) > 0 || len(table.Rows) <= cleanupBaseRows(resolvedData, table) {
+			continue
+		}
+		missing = append(missing, tableID)
+	}
+	if len(missing) > 0 {
+		return NewResolveErrorf("cannot delete rows of tables without key fields: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// cleanupBaseRows returns the amount of rows of the table which were copied from the base data, and must not be
+// deleted.
+func cleanupBaseRows(resolvedData *ResolvedData, table *Table) int {
+	if resolvedData.Base != nil {
+		if baseTable, ok := resolvedData.Base.Tables[table.TableID.TableID()]; ok {
+			return len(baseTable.Rows)
+		}
+	}
+	return 0
+}
+
+// cleanup deletes the resolved data, without handling processes.
+func cleanup(ctx context.Context, resolvedData *ResolvedData, callback ResolveCallback) error {
+	for i := len(resolvedData.TableOrder) - 1; i >= 0; i-- {
+		table, ok := resolvedData.Tables[resolvedData.TableOrder[i]]
+		if !ok {
+			continue
+		}
+
+		resolveInfo := ResolveInfo{
+			Type:            ResolveTypeDelete,
+			TableID:         table.TableID,
+			UpdateKeyFields: table.KeyFields,
+			uuidGen:         resolvedData.uuidGen,
+		}
+		for j := len(table.Rows) - 1; j >= cleanupBaseRows(resolvedData, table); j-- {
+			err := callback(ctx, resolveInfo, table.Rows[j].Values)
+			if err != nil {
+				return NewResolveErrorf("error deleting table '%s' row: %w", table.TableID.TableID(), err)
+			}
+		}
+	}
+	return nil
+}
+
+type CleanupOption func(options *cleanupOptions)
+
+// WithCleanupOptionProcess adds a Process to the cleanup.
+func WithCleanupOptionProcess(process Process) CleanupOption {
+	return func(options *cleanupOptions) {
+		options.processes = append(options.processes, process)
+	}
+}
+
+type cleanupOptions struct {
+	processes []Process
+}
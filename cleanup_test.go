@@ -0,0 +1,105 @@
+package debefix
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestCleanup(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.SetTableKeyFields(tableTags, "tag_id")
+	data.SetTableKeyFields(tablePosts, "post_id")
+
+	data.AddValues(tableTags,
+		MapValues{
+			"_refid": SetValueRefID("go"),
+			"tag_id": 1,
+		},
+		MapValues{
+			"tag_id": 2,
+		},
+	)
+	data.Add(tablePosts, MapValues{
+		"post_id": 10,
+		"tag_id":  ValueRefID(tableTags, "go", "tag_id"),
+	})
+	data.Update(ValueRefID(tableTags, "go", "tag_id").UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"name": "Go"}})
+
+	resolvedData, err := Resolve(ctx, data, ResolveCheckCallback)
+	assert.NilError(t, err)
+
+	type call struct {
+		resolveType ResolveType
+		tableID     string
+		keyFields   []string
+		values      map[string]any
+	}
+	var calls []call
+
+	err = Cleanup(ctx, resolvedData, func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+		calls = append(calls, call{
+			resolveType: resolveInfo.Type,
+			tableID:     resolveInfo.TableID.TableID(),
+			keyFields:   resolveInfo.UpdateKeyFields,
+			values:      maps.Collect(values.All),
+		})
+		return nil
+	})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, []call{
+		{ResolveTypeDelete, tablePosts.TableID(), []string{"post_id"}, map[string]any{"post_id": 10, "tag_id": 1}},
+		{ResolveTypeDelete, tableTags.TableID(), []string{"tag_id"}, map[string]any{"tag_id": 2}},
+		{ResolveTypeDelete, tableTags.TableID(), []string{"tag_id"}, map[string]any{"tag_id": 1, "name": "Go"}},
+	}, calls, cmp.AllowUnexported(call{}))
+}
+
+func TestCleanupError(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.SetTableKeyFields(tableTags, "tag_id")
+	data.Add(tableTags, MapValues{"tag_id": 1})
+
+	resolvedData, err := Resolve(ctx, data, ResolveCheckCallback)
+	assert.NilError(t, err)
+
+	cleanupErr := errors.New("cleanup error")
+	var calls []string
+	err = Cleanup(ctx, resolvedData, func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+		return cleanupErr
+	}, WithCleanupOptionProcess(testProcess{name: "p1", calls: &calls}))
+	AssertIsResolveError(t, err)
+	assert.ErrorIs(t, err, cleanupErr)
+	assert.DeepEqual(t, []string{"start:p1", "abort:p1"}, calls)
+}
+
+func TestCleanupNoKeyFields(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.SetTableKeyFields(tableTags, "tag_id")
+	data.Add(tableTags, MapValues{"tag_id": 1})
+	data.Add(tablePosts, MapValues{"post_id": 10})
+
+	resolvedData, err := Resolve(ctx, data, ResolveCheckCallback)
+	assert.NilError(t, err)
+
+	var calls []string
+	err = Cleanup(ctx, resolvedData, func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+		calls = append(calls, resolveInfo.TableID.TableID())
+		return nil
+	}, WithCleanupOptionProcess(testProcess{name: "p1", calls: &calls}))
+	AssertIsResolveError(t, err)
+	assert.ErrorContains(t, err, "cannot delete rows of tables without key fields: "+tablePosts.TableID())
+	assert.Assert(t, is.Len(calls, 0))
+}
@@ -0,0 +1,17 @@
+package debefix
+
+import "time"
+
+// Clock returns the current time. It is used to set the ResolvedData.BaseTime when resolving.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is a functional implementation of Clock.
+type ClockFunc func() time.Time
+
+var _ Clock = ClockFunc(nil)
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
@@ -0,0 +1,102 @@
+// Command debefix-extract reads rows of a SQLite database and writes them as a JSON fixture or as Go code which
+// builds an equivalent debefix.Data, using [sql.Extract].
+//
+// Usage:
+//
+//	debefix-extract -db <file> [-table <table>[:<where>]]... [-format json|go] [-package <name>] [-func <name>] [-o <file>]
+//
+// For example, to extract a tag and all posts into a JSON fixture:
+//
+//	debefix-extract -db staging.db -table "tags:tag_id = 1" -table posts -o fixtures/staging.json
+//
+// All tables are read if no table is selected. For other databases, call [sql.Extract] with their dialect and
+// introspector packages.
+package main
+
+import (
+	"bytes"
+	"context"
+	gosql "database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rrgmc/debefix/v2"
+	"github.com/rrgmc/debefix/v2/fixture"
+	"github.com/rrgmc/debefix/v2/sql"
+	"github.com/rrgmc/debefix/v2/sql/sqlite"
+	_ "modernc.org/sqlite"
+)
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("debefix-extract", flag.ContinueOnError)
+	dbFile := flags.String("db", "", "SQLite database file")
+	format := flags.String("format", "json", "output format, json or go")
+	packageName := flags.String("package", "fixtures", "package name of the Go code")
+	funcName := flags.String("func", "", "function name of the Go code")
+	output := flags.String("o", "", "output file, standard output if blank")
+	var options []sql.ExtractOption
+	flags.Func("table", "table to read, with an optional condition after a colon, like \"tags:tag_id = 1\"",
+		func(value string) error {
+			tableName, where, _ := strings.Cut(value, ":")
+			if tableName == "" {
+				return errors.New("table name can't be blank")
+			}
+			options = append(options, sql.WithExtractTable(debefix.TableName(tableName), where))
+			return nil
+		})
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbFile == "" {
+		return errors.New("the database file must be set using -db")
+	}
+	if *format != "json" && *format != "go" {
+		return fmt.Errorf("unknown format '%s'", *format)
+	}
+
+	if _, err := os.Stat(*dbFile); err != nil {
+		return err
+	}
+	db, err := gosql.Open("sqlite", *dbFile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	data, err := sql.Extract(ctx, db, sqlite.Dialect(), sqlite.Introspector(), options...)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	switch *format {
+	case "go":
+		var goOptions []fixture.GoOption
+		if *funcName != "" {
+			goOptions = append(goOptions, fixture.WithGoFuncName(*funcName))
+		}
+		err = fixture.WriteGo(&buf, data, *packageName, goOptions...)
+	default:
+		err = fixture.WriteJSON(&buf, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		return os.WriteFile(*output, buf.Bytes(), 0o644)
+	}
+	_, err = stdout.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	gosql "database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rrgmc/debefix/v2"
+	"github.com/rrgmc/debefix/v2/fixture"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := gosql.Open("sqlite", dbFile)
+	assert.NilError(t, err)
+	_, err = db.Exec(`
+create table tags (
+	tag_id integer primary key,
+	name text not null
+);
+insert into tags (tag_id, name) values (1, 'Go'), (2, 'JavaScript');
+`)
+	assert.NilError(t, err)
+	assert.NilError(t, db.Close())
+
+	var stdout bytes.Buffer
+	err = run(ctx, []string{"-db", dbFile, "-table", "tags:tag_id = 2"}, &stdout)
+	assert.NilError(t, err)
+
+	data := debefix.NewData()
+	err = fixture.LoadJSON(data, &stdout)
+	assert.NilError(t, err)
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{"tag_id": debefix.ResolveValueResolve(), "name": "JavaScript"},
+	}, data.Tables["tags"].Rows)
+
+	output := filepath.Join(t.TempDir(), "fixtures.go")
+	err = run(ctx, []string{"-db", dbFile, "-format", "go", "-package", "testfixtures", "-o", output}, &stdout)
+	assert.NilError(t, err)
+	code, err := os.ReadFile(output)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Contains(string(code), "package testfixtures"))
+	assert.Assert(t, is.Contains(string(code), `debefix.SetValueRefID("tags_2")`))
+
+	err = run(ctx, []string{"-db", filepath.Join(t.TempDir(), "missing.db")}, &stdout)
+	assert.Assert(t, err != nil)
+	err = run(ctx, []string{"-db", dbFile, "-format", "yaml"}, &stdout)
+	assert.ErrorContains(t, err, "unknown format 'yaml'")
+}
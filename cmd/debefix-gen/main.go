@@ -0,0 +1,110 @@
+// Command debefix-gen loads fixture files and writes Go code declaring typed references to their tables and RefIDs,
+// using [fixture.WriteGoRefs], or a function which builds the same debefix.Data, using [fixture.WriteGo].
+//
+// Usage:
+//
+//	debefix-gen [-mode refs|data] [-package <name>] [-func <name>] [-o <file>] <file or directory>...
+//
+// Directories are loaded using [fixture.LoadDir], and files using the loader of their extension from
+// [fixture.DefaultFileLoaders]. The package name defaults to the $GOPACKAGE environment variable set by go generate,
+// so it can be used in the package which uses the references:
+//
+//	//go:generate go run github.com/rrgmc/debefix/v2/cmd/debefix-gen -o fixtures_refs.go ./fixtures
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rrgmc/debefix/v2"
+	"github.com/rrgmc/debefix/v2/fixture"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	defaultPackage := os.Getenv("GOPACKAGE")
+	if defaultPackage == "" {
+		defaultPackage = "fixtures"
+	}
+
+	flags := flag.NewFlagSet("debefix-gen", flag.ContinueOnError)
+	mode := flags.String("mode", "refs", "generated code, refs (typed references) or data (function building the data)")
+	packageName := flags.String("package", defaultPackage, "package name of the Go code")
+	funcName := flags.String("func", "", "function name of the Go code, for the data mode")
+	output := flags.String("o", "", "output file, standard output if blank")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("at least one fixture file or directory must be passed")
+	}
+	if *mode != "refs" && *mode != "data" {
+		return fmt.Errorf("unknown mode '%s'", *mode)
+	}
+
+	data := debefix.NewData()
+	for _, fileName := range flags.Args() {
+		if err := loadPath(data, fileName); err != nil {
+			return err
+		}
+	}
+	if err := data.Err(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch *mode {
+	case "data":
+		var goOptions []fixture.GoOption
+		if *funcName != "" {
+			goOptions = append(goOptions, fixture.WithGoFuncName(*funcName))
+		}
+		err = fixture.WriteGo(&buf, data, *packageName, goOptions...)
+	default:
+		err = fixture.WriteGoRefs(&buf, data, *packageName)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		return os.WriteFile(*output, buf.Bytes(), 0o644)
+	}
+	_, err = stdout.Write(buf.Bytes())
+	return err
+}
+
+// loadPath loads a fixture file, or all fixture files of a directory.
+func loadPath(data *debefix.Data, fileName string) error {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fixture.LoadDir(data, fileName)
+	}
+
+	loader, ok := fixture.DefaultFileLoaders()[strings.ToLower(filepath.Ext(fileName))]
+	if !ok {
+		return fmt.Errorf("unknown fixture file type '%s'", fileName)
+	}
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return loader(data, f, fixture.WithLoadFileName(fileName))
+}
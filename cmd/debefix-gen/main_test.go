@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, "fixtures"), 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "fixtures", "users.yaml"), []byte(`
+users:
+  rows:
+    - user_id: 1
+      _refid: !refid john_doe
+`), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "tags.csv"), []byte("_refid,tag_id:int\ngo,1\n"), 0o644))
+
+	t.Setenv("GOPACKAGE", "testfixtures")
+
+	var stdout bytes.Buffer
+	err := run([]string{filepath.Join(dir, "fixtures"), filepath.Join(dir, "tags.csv")}, &stdout)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Contains(stdout.String(), "package testfixtures"))
+	assert.Assert(t, is.Contains(stdout.String(), "var Users = usersTable{"))
+	assert.Assert(t, is.Contains(stdout.String(), "func (r usersRow) UserID() debefix.ValueRefIDData"))
+	assert.Assert(t, is.Contains(stdout.String(), "var Tags = tagsTable{"))
+
+	output := filepath.Join(dir, "fixtures_data.go")
+	err = run([]string{"-mode", "data", "-package", "seed", "-o", output, filepath.Join(dir, "fixtures")}, &stdout)
+	assert.NilError(t, err)
+	code, err := os.ReadFile(output)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Contains(string(code), "package seed"))
+	assert.Assert(t, is.Contains(string(code), `debefix.SetValueRefID("john_doe")`))
+
+	err = run(nil, &stdout)
+	assert.ErrorContains(t, err, "at least one fixture file or directory")
+	err = run([]string{filepath.Join(dir, "fixtures", "users.txt")}, &stdout)
+	assert.Assert(t, err != nil)
+	err = run([]string{"-mode", "json", filepath.Join(dir, "fixtures")}, &stdout)
+	assert.ErrorContains(t, err, "unknown mode 'json'")
+}
@@ -0,0 +1,474 @@
+package debefix
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// breakCycles returns a copy of data where dependency cycles between tables, and references to rows of the same table
+// which are added later, are broken. The fields causing them are removed from the rows, and global updates are
+// added to set them after all rows were added, using the table's key fields. Breakable dependencies causing cycles
+// between tables are removed.
+// If there is nothing to break, data itself is returned.
+func breakCycles(data *Data) (*Data, error) {
+	graph := newTableGraph(data)
+
+	// fields to be removed from each row.
+	broken := map[*Row][]string{}
+	// breakable dependencies to be removed from each table.
+	brokenDeps := map[string][]string{}
+
+	// break cycles between tables.
+	sccOf := map[string]int{}
+	for sccIdx, scc := range graph.cycles() {
+		for _, tableID := range scc {
+			sccOf[tableID] = sccIdx
+		}
+		order, err := graph.cycleOrder(scc)
+		if err != nil {
+			return nil, err
+		}
+		position := map[string]int{}
+		for idx, tableID := range order {
+			position[tableID] = idx
+		}
+		for _, tableID := range order {
+			for _, edge := range graph.fieldEdges[tableID] {
+				if pos, ok := position[edge.dependsOn]; ok && pos > position[tableID] &&
+					!slices.Contains(broken[edge.row], edge.fieldName) {
+					broken[edge.row] = append(broken[edge.row], edge.fieldName)
+				}
+			}
+			for _, dep := range graph.breakableDeps[tableID] {
+				if pos, ok := position[dep.TableID()]; ok && pos > position[tableID] {
+					brokenDeps[tableID] = append(brokenDeps[tableID], dep.TableID())
+				}
+			}
+		}
+	}
+
+	// break references to rows of the same table which are added later.
+	for _, tableID := range graph.tableIDs {
+		table := data.Tables[tableID]
+		rowIndex := map[any]int{}
+		for idx, row := range table.Rows {
+			rowIndex[row.InternalID] = idx
+			if row.RefID != "" {
+				if _, ok := rowIndex[row.RefID]; !ok {
+					rowIndex[row.RefID] = idx
+				}
+			}
+		}
+		for rowIdx, row := range table.Rows {
+			for _, fieldName := range sortedFieldNames(row.Values) {
+				for _, ref := range valueRowRefs(row.Values.GetOrNil(fieldName)) {
+					if ref.tableID.TableID() != tableID {
+						continue
+					}
+					if refIdx, ok := rowIndex[ref.key()]; ok && refIdx >= rowIdx &&
+						!slices.Contains(broken[row], fieldName) {
+						broken[row] = append(broken[row], fieldName)
+					}
+				}
+			}
+		}
+	}
+
+	if len(broken) == 0 && len(brokenDeps) == 0 {
+		return data, nil
+	}
+
+	ret := &Data{
+		Tables: make(map[string]*Table, len(data.Tables)),
+		err:    data.err,
+	}
+	var cycleUpdates []Update
+	for _, tableID := range graph.tableIDs {
+		table := data.Tables[tableID]
+		newTable := table.emptyCopy()
+		newTable.Rows = make([]*Row, 0, len(table.Rows))
+		newTable.AddDependencies(graph.explicitDeps[tableID]...)
+		for _, dep := range table.BreakableDepends {
+			if !slices.Contains(brokenDeps[tableID], dep.TableID()) {
+				newTable.AddBreakableDependencies(dep)
+			}
+		}
+
+		for _, row := range table.Rows {
+			brokenFields, ok := broken[row]
+			if !ok {
+				newTable.Rows = append(newTable.Rows, row)
+				newTable.AddDependencies(rowTableDependencies(row)...)
+				continue
+			}
+			if len(table.KeyFields) == 0 {
+				return nil, NewResolveErrorf("%w", graph.brokenFieldCycleError(sccOf, table, row, brokenFields,
+					fmt.Sprintf("table '%s' has no key fields to update the fields later", tableID)))
+			}
+
+			newRow := *row
+			newRow.Values = MapValues(maps.Collect(row.Values.All))
+			updateValues := MapValues{}
+			for _, fieldName := range brokenFields {
+				updateValues[fieldName] = row.Values.GetOrNil(fieldName)
+				newRow.Values.Delete(fieldName)
+			}
+			newTable.Rows = append(newTable.Rows, &newRow)
+			newTable.AddDependencies(rowTableDependencies(&newRow)...)
+
+			cycleUpdates = append(cycleUpdates, Update{
+				Query:  NewInternalIDRef(table.TableID, row.InternalID).UpdateQuery(table.KeyFields),
+				Action: UpdateActionSetValues{Values: updateValues},
+			})
+		}
+		ret.Tables[tableID] = newTable
+	}
+	ret.Updates = append(cycleUpdates, data.Updates...)
+
+	return ret, nil
+}
+
+// tableGraph is the dependency graph between tables, separating the dependencies which come from row field values
+// or were added as breakable, which can be broken, from the explicit ones.
+type tableGraph struct {
+	tableIDs      []string                  // sorted table ids.
+	edges         map[string][]string       // table dependencies, sorted.
+	fieldEdges    map[string][]tableEdge    // dependencies caused by row field values.
+	explicitDeps  map[string][]TableID      // dependencies not caused by row field values.
+	breakableDeps map[string][]TableID      // breakable dependencies on tables of the data.
+	tables        map[string]TableID        // table id of each table.
+	fieldCount    map[string]map[string]int // amount of field values of a table which depends on another table.
+}
+
+// tableEdge is a dependency caused by a row field value.
+type tableEdge struct {
+	dependsOn string
+	row       *Row
+	fieldName string
+	value     ValueDependencies
+}
+
+func newTableGraph(data *Data) *tableGraph {
+	ret := &tableGraph{
+		tableIDs:      slices.Sorted(maps.Keys(data.Tables)),
+		edges:         map[string][]string{},
+		fieldEdges:    map[string][]tableEdge{},
+		explicitDeps:  map[string][]TableID{},
+		breakableDeps: map[string][]TableID{},
+		tables:        map[string]TableID{},
+		fieldCount:    map[string]map[string]int{},
+	}
+
+	for _, tableID := range ret.tableIDs {
+		table := data.Tables[tableID]
+		ret.tables[tableID] = table.TableID
+		ret.fieldCount[tableID] = map[string]int{}
+		for _, row := range table.Rows {
+			for _, fieldName := range sortedFieldNames(row.Values) {
+				vd, ok := row.Values.GetOrNil(fieldName).(ValueDependencies)
+				if !ok {
+					continue
+				}
+				for _, dep := range vd.TableDependencies() {
+					ret.fieldEdges[tableID] = append(ret.fieldEdges[tableID], tableEdge{
+						dependsOn: dep.TableID(),
+						row:       row,
+						fieldName: fieldName,
+						value:     vd,
+					})
+					if dep.TableID() != tableID {
+						ret.fieldCount[tableID][dep.TableID()]++
+					}
+				}
+			}
+		}
+
+		for _, dep := range table.Depends {
+			if _, ok := ret.fieldCount[tableID][dep.TableID()]; !ok {
+				ret.explicitDeps[tableID] = append(ret.explicitDeps[tableID], dep)
+			}
+			if _, ok := data.Tables[dep.TableID()]; ok && !slices.Contains(ret.edges[tableID], dep.TableID()) {
+				ret.edges[tableID] = append(ret.edges[tableID], dep.TableID())
+			}
+		}
+		for _, dep := range table.BreakableDepends {
+			if !hasRowsToResolve(data, dep.TableID()) {
+				continue
+			}
+			ret.breakableDeps[tableID] = append(ret.breakableDeps[tableID], dep)
+			if !slices.Contains(ret.edges[tableID], dep.TableID()) {
+				ret.edges[tableID] = append(ret.edges[tableID], dep.TableID())
+			}
+		}
+		slices.Sort(ret.edges[tableID])
+	}
+
+	return ret
+}
+
+// cycles returns the strongly connected components with more than one table, using Tarjan's algorithm.
+func (g *tableGraph) cycles() [][]string {
+	var ret [][]string
+	index := map[string]int{}
+	lowLink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+
+	var connect func(tableID string)
+	connect = func(tableID string) {
+		index[tableID] = len(index)
+		lowLink[tableID] = index[tableID]
+		stack = append(stack, tableID)
+		onStack[tableID] = true
+
+		for _, dep := range g.edges[tableID] {
+			if _, ok := index[dep]; !ok {
+				connect(dep)
+				lowLink[tableID] = min(lowLink[tableID], lowLink[dep])
+			} else if onStack[dep] {
+				lowLink[tableID] = min(lowLink[tableID], index[dep])
+			}
+		}
+
+		if lowLink[tableID] == index[tableID] {
+			var scc []string
+			for {
+				item := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[item] = false
+				scc = append(scc, item)
+				if item == tableID {
+					break
+				}
+			}
+			if len(scc) > 1 {
+				slices.Sort(scc)
+				ret = append(ret, scc)
+			}
+		}
+	}
+
+	for _, tableID := range g.tableIDs {
+		if _, ok := index[tableID]; !ok {
+			connect(tableID)
+		}
+	}
+	return ret
+}
+
+// cycleOrder returns the order in which the tables of a cycle should be resolved. Explicit dependencies are always
+// respected, and tables with fewer field values depending on tables not yet resolved are preferred.
+func (g *tableGraph) cycleOrder(scc []string) ([]string, error) {
+	var ret []string
+	remaining := slices.Clone(scc)
+	for len(remaining) > 0 {
+		best, bestCount := -1, 0
+		for idx, tableID := range remaining {
+			if slices.ContainsFunc(g.explicitDeps[tableID], func(dep TableID) bool {
+				return dep.TableID() != tableID && slices.Contains(remaining, dep.TableID())
+			}) {
+				continue
+			}
+			count := 0
+			for _, dep := range remaining {
+				count += g.fieldCount[tableID][dep]
+			}
+			if best < 0 || count < bestCount {
+				best, bestCount = idx, count
+			}
+		}
+		if best < 0 {
+			return nil, NewResolveErrorf("%w", g.cycleError(g.explicitCycle(remaining),
+				"it contains explicit dependencies"))
+		}
+		ret = append(ret, remaining[best])
+		remaining = slices.Delete(remaining, best, best+1)
+	}
+	return ret, nil
+}
+
+// explicitCycle returns a cycle formed only by explicit dependencies between the tables, where each of them has an
+// explicit dependency on another one.
+func (g *tableGraph) explicitCycle(tableIDs []string) []string {
+	visited := map[string]int{}
+	var path []string
+	current := tableIDs[0]
+	for {
+		if idx, ok := visited[current]; ok {
+			return append(path[idx:], current)
+		}
+		visited[current] = len(path)
+		path = append(path, current)
+		for _, dep := range g.explicitDeps[current] {
+			if dep.TableID() != current && slices.Contains(tableIDs, dep.TableID()) {
+				current = dep.TableID()
+				break
+			}
+		}
+	}
+}
+
+// findPath returns the shortest dependency path between two different tables, or nil if there is none.
+func (g *tableGraph) findPath(from, to string) []string {
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			var ret []string
+			for ; current != ""; current = prev[current] {
+				ret = append(ret, current)
+			}
+			slices.Reverse(ret)
+			return ret
+		}
+		for _, dep := range g.edges[current] {
+			if _, ok := prev[dep]; !ok {
+				prev[dep] = current
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return nil
+}
+
+// cycleError returns a CycleError for the path of tables, listing the causes of each dependency.
+func (g *tableGraph) cycleError(path []string, reason string) *CycleError {
+	ret := &CycleError{Reason: reason}
+	for idx, tableID := range path {
+		ret.Path = append(ret.Path, g.tables[tableID])
+		if idx == 0 {
+			continue
+		}
+		from := path[idx-1]
+		edge := CycleEdge{
+			TableID:   g.tables[from],
+			DependsOn: g.tables[tableID],
+			Explicit: slices.ContainsFunc(g.explicitDeps[from], func(dep TableID) bool {
+				return dep.TableID() == tableID
+			}),
+			Breakable: slices.ContainsFunc(g.breakableDeps[from], func(dep TableID) bool {
+				return dep.TableID() == tableID
+			}),
+		}
+		for _, fieldEdge := range g.fieldEdges[from] {
+			if fieldEdge.dependsOn == tableID {
+				edge.Fields = append(edge.Fields, fieldEdge.cycleEdgeField())
+			}
+		}
+		ret.Edges = append(ret.Edges, edge)
+	}
+	return ret
+}
+
+// brokenFieldCycleError returns a CycleError for the cycle which caused the fields of the row to be broken.
+func (g *tableGraph) brokenFieldCycleError(sccOf map[string]int, table *Table, row *Row, fieldNames []string,
+	reason string) *CycleError {
+	tableID := table.TableID.TableID()
+	for _, fieldEdge := range g.fieldEdges[tableID] {
+		if fieldEdge.row != row || !slices.Contains(fieldNames, fieldEdge.fieldName) || fieldEdge.dependsOn == tableID {
+			continue
+		}
+		if sccIdx, ok := sccOf[fieldEdge.dependsOn]; ok && sccIdx == sccOf[tableID] {
+			return g.cycleError(append([]string{tableID}, g.findPath(fieldEdge.dependsOn, tableID)...), reason)
+		}
+	}
+
+	// reference to a row of the same table which is added later.
+	edge := CycleEdge{
+		TableID:   table.TableID,
+		DependsOn: table.TableID,
+	}
+	for _, fieldEdge := range g.fieldEdges[tableID] {
+		if fieldEdge.row == row && slices.Contains(fieldNames, fieldEdge.fieldName) && fieldEdge.dependsOn == tableID {
+			edge.Fields = append(edge.Fields, fieldEdge.cycleEdgeField())
+		}
+	}
+	return &CycleError{
+		Path:   []TableID{table.TableID, table.TableID},
+		Edges:  []CycleEdge{edge},
+		Reason: reason,
+	}
+}
+
+func (e tableEdge) cycleEdgeField() CycleEdgeField {
+	return CycleEdgeField{
+		InternalID: e.row.InternalID,
+		RefID:      e.row.RefID,
+		FieldName:  e.fieldName,
+		Value:      e.value,
+	}
+}
+
+// rowTableDependencies returns the table dependencies of the row field values.
+func rowTableDependencies(row *Row) []TableID {
+	var ret []TableID
+	for _, fieldValue := range row.Values.All {
+		if vd, ok := fieldValue.(ValueDependencies); ok {
+			ret = append(ret, vd.TableDependencies()...)
+		}
+	}
+	return ret
+}
+
+// rowRef is a reference to a single table row, by RefID or internal id.
+type rowRef struct {
+	tableID    TableID
+	refID      RefID
+	internalID uuid.UUID
+}
+
+func (r rowRef) key() any {
+	if r.refID != "" {
+		return r.refID
+	}
+	return r.internalID
+}
+
+// valueRowRefs returns the rows directly referenced by a field value.
+func valueRowRefs(value any) []rowRef {
+	switch vv := value.(type) {
+	case ValueRefIDData:
+		return []rowRef{{tableID: vv.TableID, refID: vv.RefID}}
+	case ValueInternalIDData:
+		return []rowRef{{tableID: vv.TableID, internalID: vv.InternalID}}
+	case ValueDefaultData:
+		return valueRowRefs(vv.Value)
+	case ValueFormatFuncData:
+		return valueRowRefs(vv.Value)
+	case ValueFormatData:
+		var ret []rowRef
+		for _, arg := range vv.Args {
+			ret = append(ret, valueRowRefs(arg)...)
+		}
+		return ret
+	case ValueTemplateData:
+		var ret []rowRef
+		for _, argName := range slices.Sorted(maps.Keys(vv.Args)) {
+			ret = append(ret, valueRowRefs(vv.Args[argName])...)
+		}
+		return ret
+	default:
+		return nil
+	}
+}
+
+// sortedFieldNames returns the field names of the values in sorted order.
+func sortedFieldNames(values Values) []string {
+	var ret []string
+	for fieldName := range values.All {
+		ret = append(ret, fieldName)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// hasRowsToResolve returns whether the table exists in data and has rows to resolve.
+func hasRowsToResolve(data *Data, tableID string) bool {
+	table, ok := data.Tables[tableID]
+	return ok && len(table.Rows) > 0
+}
@@ -2,6 +2,8 @@ package debefix
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 
 	"github.com/google/uuid"
 )
@@ -11,16 +13,33 @@ type Data struct {
 	Tables  map[string]*Table // map key is TableID.TableID()
 	Updates []Update          // list of updates to be executed after all rows were added.
 	err     error
+	uuidGen *uuidGenerator
+	strict  bool
 }
 
 // NewData creates a new Data instance.
-func NewData() *Data {
-	return &Data{
+func NewData(options ...DataOption) *Data {
+	var optns dataOptions
+	for _, opt := range options {
+		opt(&optns)
+	}
+
+	ret := &Data{
 		Tables: make(map[string]*Table),
 	}
+	if optns.uuidSeed != nil {
+		ret.uuidGen = newUUIDGenerator(*optns.uuidSeed)
+	}
+	ret.strict = optns.strict
+	return ret
+}
+
+// NewUUID returns a new random UUID. If a UUID seed was set, the same sequence of UUIDs is returned on every run.
+func (d *Data) NewUUID() uuid.UUID {
+	return d.uuidGen.New()
 }
 
-// Err returns any error generated in processing.
+// Err returns any error generated in processing, like RefIDs duplicated in the same table.
 func (d *Data) Err() error {
 	return d.err
 }
@@ -49,10 +68,23 @@ func (d *Data) AddWithID(tableID TableID, values ValuesMutable, options ...DataA
 			TableID: tableID,
 		}
 	}
-	row := d.newRow(d.Tables[tableID.TableID()], values)
-	d.Tables[tableID.TableID()].Rows = append(d.Tables[tableID.TableID()].Rows, row)
+	table := d.Tables[tableID.TableID()]
+	row := d.newRow(table, values)
+	row.Origin = optns.origin
+	if row.RefID != "" {
+		if existing := table.FindRefIDRow(row.RefID); existing != nil {
+			d.addError(NewResolveErrorf("%w '%s' in table '%s': %s was already defined by %s", ErrDuplicateRefID,
+				row.RefID, tableID.TableID(), rowOrigin(row, len(table.Rows)),
+				rowOrigin(existing, slices.Index(table.Rows, existing))))
+		}
+	}
+	if table.Schema != nil {
+		d.prepareSchemaRow(table, row, len(table.Rows))
+	}
+	table.Rows = append(table.Rows, row)
 
 	row.ResolvedCallbacks = optns.resolvedCallbacks
+	row.Tags = optns.tags
 
 	return NewInternalIDRef(tableID, row.InternalID)
 }
@@ -88,9 +120,73 @@ func (d *Data) AddDependencies(tableID TableID, dependencies ...TableID) {
 	d.Tables[tableID.TableID()].AddDependencies(dependencies...)
 }
 
+// AddBreakableDependencies adds dependencies on other tables to the passed table, which are respected when ordering
+// the tables, but are ignored if they are part of a circular dependency, like the ones of database foreign keys.
+// Dependencies on tables which don't have rows to resolve are ignored.
+func (d *Data) AddBreakableDependencies(tableID TableID, dependencies ...TableID) {
+	if _, ok := d.Tables[tableID.TableID()]; !ok {
+		d.Tables[tableID.TableID()] = &Table{
+			TableID: tableID,
+		}
+	}
+	d.Tables[tableID.TableID()].AddBreakableDependencies(dependencies...)
+}
+
+// SetTableKeyFields sets the fields which uniquely identify a row of the table, like its primary key.
+// They are used to update rows when breaking circular dependencies.
+func (d *Data) SetTableKeyFields(tableID TableID, keyFields ...string) {
+	if _, ok := d.Tables[tableID.TableID()]; !ok {
+		d.Tables[tableID.TableID()] = &Table{
+			TableID: tableID,
+		}
+	}
+	d.Tables[tableID.TableID()].KeyFields = keyFields
+}
+
+// SetTableTags sets tags which apply to all rows of the table, in addition to their own tags.
+// They are used to filter rows using WithResolveOptionTagFilter.
+func (d *Data) SetTableTags(tableID TableID, tags ...string) {
+	if _, ok := d.Tables[tableID.TableID()]; !ok {
+		d.Tables[tableID.TableID()] = &Table{
+			TableID: tableID,
+		}
+	}
+	d.Tables[tableID.TableID()].Tags = tags
+}
+
+// SetTableSchema sets the schema of the table columns. Rows added to the table are validated against it, and receive
+// the column defaults and ResolveValueResolve values for omitted identity columns. Rows which were already added are
+// also validated. Validation errors are returned by Err.
+// If the table has no key fields, its primary key columns are used as the key fields.
+func (d *Data) SetTableSchema(tableID TableID, schema *TableSchema) {
+	if _, ok := d.Tables[tableID.TableID()]; !ok {
+		d.Tables[tableID.TableID()] = &Table{
+			TableID: tableID,
+		}
+	}
+	table := d.Tables[tableID.TableID()]
+	table.Schema = schema
+	if len(table.KeyFields) == 0 {
+		table.KeyFields = schema.PrimaryKey()
+	}
+	for rowIdx, row := range table.Rows {
+		d.prepareSchemaRow(table, row, rowIdx)
+	}
+}
+
+// prepareSchemaRow prepares the row values using the table schema, recording any validation error.
+func (d *Data) prepareSchemaRow(table *Table, row *Row, pos int) {
+	if err := table.Schema.prepareRow(row.Values); err != nil {
+		d.addError(NewResolveErrorf("%s of table '%s' doesn't match the table schema: %w", rowOrigin(row, pos),
+			table.TableID.TableID(), err))
+	}
+	// default values may depend on other tables.
+	table.AddDependencies(rowTableDependencies(row)...)
+}
+
 func (d *Data) newRow(table *Table, values ValuesMutable) *Row {
 	ret := &Row{
-		InternalID: uuid.New(),
+		InternalID: d.NewUUID(),
 		Values:     values,
 	}
 
@@ -199,16 +295,11 @@ func (d *Data) FindTableRowValue(tableID TableID, fieldName string, f func(*Row)
 
 // FindInternalIDRow returns a row of a table based on its internal id.
 func (d *Data) FindInternalIDRow(tableID TableID, internalID uuid.UUID) (*Row, error) {
-	row, err := d.FindTableRow(tableID, func(row *Row) (bool, error) {
-		if row.InternalID == internalID {
-			return true, nil
-		}
-		return false, nil
-	})
-	if err != nil && !errors.Is(err, ResolveNoRows) {
-		return nil, err
+	t, ok := d.Tables[tableID.TableID()]
+	if !ok {
+		return nil, NewResolveErrorf("table %s not found", tableID)
 	}
-	if err == nil {
+	if row := t.FindInternalIDRow(internalID); row != nil {
 		return row, nil
 	}
 	return nil, NewResolveErrorf("internal ID %v not found in table '%s'", internalID, tableID)
@@ -225,16 +316,11 @@ func (d *Data) FindInternalIDValue(value ValueInternalIDData) (any, error) {
 
 // FindRefIDRow returns a row from a table using a RefID.
 func (d *Data) FindRefIDRow(tableID TableID, refID RefID) (*Row, error) {
-	row, err := d.FindTableRow(tableID, func(row *Row) (bool, error) {
-		if row.RefID == refID {
-			return true, nil
-		}
-		return false, nil
-	})
-	if err != nil && !errors.Is(err, ResolveNoRows) {
-		return nil, err
+	t, ok := d.Tables[tableID.TableID()]
+	if !ok {
+		return nil, NewResolveErrorf("table %s not found", tableID)
 	}
-	if err == nil {
+	if row := t.FindRefIDRow(refID); row != nil {
 		return row, nil
 	}
 	return nil, NewResolveErrorf("refID %v not found in table '%s'", refID, tableID)
@@ -249,10 +335,46 @@ func (d *Data) FindRefIDRowValue(value ValueRefIDData) (any, error) {
 	return row.ResolveFieldName(value.FieldName)
 }
 
+// addError records an error to be returned by Err, or panics if the data is strict.
 func (d *Data) addError(err error) {
+	if d.strict {
+		panic(err)
+	}
 	d.err = errors.Join(d.err, err)
 }
 
+// rowOrigin describes a row by its position in the table and its origin, if set.
+func rowOrigin(row *Row, pos int) string {
+	if row.Origin != "" {
+		return fmt.Sprintf("row %d (%s)", pos, row.Origin)
+	}
+	return fmt.Sprintf("row %d", pos)
+}
+
+// DataOption are options for [NewData].
+type DataOption func(options *dataOptions)
+
+// WithDataUUIDSeed generates the row internal ids from a random source using the passed seed, so the same internal
+// ids are generated on every run if rows are added in the same order.
+func WithDataUUIDSeed(seed int64) DataOption {
+	return func(options *dataOptions) {
+		options.uuidSeed = &seed
+	}
+}
+
+// WithDataStrict panics when an error is found while adding data, like a duplicated RefID, instead of returning it
+// in [Data.Err]. It is useful in tests, to fail at the line where the invalid data was added.
+func WithDataStrict() DataOption {
+	return func(options *dataOptions) {
+		options.strict = true
+	}
+}
+
+type dataOptions struct {
+	uuidSeed *int64
+	strict   bool
+}
+
 // DataAddOption are options for [Data.Add] and [Data.AddWithID].
 type DataAddOption func(options *dataAddOptions)
 
@@ -263,6 +385,22 @@ func WithDataAddResolvedCallback(f ResolvedCallback) DataAddOption {
 	}
 }
 
+// WithDataAddTags adds tags to the row, which are used to filter rows using WithResolveOptionTagFilter.
+func WithDataAddTags(tags ...string) DataAddOption {
+	return func(options *dataAddOptions) {
+		options.tags = append(options.tags, tags...)
+	}
+}
+
+// WithDataAddOrigin sets where the row was defined, like a fixture file position, to be reported in errors.
+func WithDataAddOrigin(origin string) DataAddOption {
+	return func(options *dataAddOptions) {
+		options.origin = origin
+	}
+}
+
 type dataAddOptions struct {
 	resolvedCallbacks []ResolvedCallback
+	tags              []string
+	origin            string
 }
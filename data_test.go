@@ -1,6 +1,7 @@
 package debefix
 
 import (
+	"errors"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -99,3 +100,106 @@ func TestData(t *testing.T) {
 		},
 	}, postTagsTable.Rows)
 }
+
+func TestDataFindRow(t *testing.T) {
+	data := NewData()
+
+	allIID := data.AddWithID(tableTags, MapValues{
+		"tag_id": 2,
+		"_refid": SetValueRefID("all"),
+	})
+
+	row, err := data.FindRefIDRow(tableTags, "all")
+	assert.NilError(t, err)
+	assert.Equal(t, allIID.InternalID, row.InternalID)
+
+	// rows added after a lookup must be found.
+	halfIID := data.AddWithID(tableTags, MapValues{
+		"tag_id": 5,
+		"_refid": SetValueRefID("half"),
+	})
+
+	row, err = data.FindRefIDRow(tableTags, "half")
+	assert.NilError(t, err)
+	assert.Equal(t, halfIID.InternalID, row.InternalID)
+
+	row, err = data.FindInternalIDRow(tableTags, halfIID.InternalID)
+	assert.NilError(t, err)
+	assert.Equal(t, RefID("half"), row.RefID)
+
+	_, err = data.FindRefIDRow(tableTags, "none")
+	AssertIsResolveError(t, err)
+
+	_, err = data.FindRefIDRow(tablePosts, "all")
+	AssertIsResolveError(t, err)
+
+	// rows changed directly in the table must be found.
+	table := data.Tables[tableTags.TableID()]
+	table.Rows[0] = &Row{InternalID: data.NewUUID(), RefID: "new_all"}
+	table.Rows = table.Rows[:1]
+
+	row, err = data.FindRefIDRow(tableTags, "new_all")
+	assert.NilError(t, err)
+	assert.Equal(t, table.Rows[0], row)
+
+	_, err = data.FindRefIDRow(tableTags, "all")
+	AssertIsResolveError(t, err)
+	_, err = data.FindInternalIDRow(tableTags, halfIID.InternalID)
+	AssertIsResolveError(t, err)
+	// rows replaced in place in the middle of the table must not be found, and the new ones must be found after the
+	// index detects the change.
+	data.AddValues(tableTags,
+		MapValues{"tag_id": 6, "_refid": SetValueRefID("middle")},
+		MapValues{"tag_id": 7, "_refid": SetValueRefID("last")},
+	)
+	row, err = data.FindRefIDRow(tableTags, "middle")
+	assert.NilError(t, err)
+	middleID := row.InternalID
+
+	table.Rows[1] = &Row{InternalID: data.NewUUID(), RefID: "new_middle"}
+
+	_, err = data.FindRefIDRow(tableTags, "middle")
+	AssertIsResolveError(t, err)
+	_, err = data.FindInternalIDRow(tableTags, middleID)
+	AssertIsResolveError(t, err)
+
+	row, err = data.FindRefIDRow(tableTags, "new_middle")
+	assert.NilError(t, err)
+	assert.Equal(t, table.Rows[1], row)
+	row, err = data.FindInternalIDRow(tableTags, table.Rows[1].InternalID)
+	assert.NilError(t, err)
+	assert.Equal(t, table.Rows[1], row)
+
+	row, err = data.FindRefIDRow(tableTags, "last")
+	assert.NilError(t, err)
+	assert.Equal(t, table.Rows[2], row)
+}
+
+func TestDataDuplicateRefID(t *testing.T) {
+	data := NewData()
+	data.Add(tableTags, MapValues{
+		"tag_id": 1,
+		"_refid": SetValueRefID("go"),
+	}, WithDataAddOrigin("tags.yaml:3:5"))
+	data.Add(tableTags, MapValues{
+		"tag_id": 2,
+		"_refid": SetValueRefID("go"),
+	})
+	assert.Assert(t, errors.Is(data.Err(), ErrDuplicateRefID))
+	assert.Error(t, data.Err(), "duplicate refID 'go' in table 'public.tags': row 1 was already defined by row 0 (tags.yaml:3:5)")
+
+	// the first row is still returned.
+	row, err := data.FindRefIDRow(tableTags, "go")
+	assert.NilError(t, err)
+	assert.Equal(t, 1, row.Values.GetOrNil("tag_id"))
+
+	strictData := NewData(WithDataStrict())
+	strictData.Add(tableTags, MapValues{
+		"_refid": SetValueRefID("go"),
+	})
+	assert.Assert(t, is.Panics(func() {
+		strictData.Add(tableTags, MapValues{
+			"_refid": SetValueRefID("go"),
+		})
+	}))
+}
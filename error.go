@@ -3,10 +3,14 @@ package debefix
 import (
 	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
 )
 
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound       = errors.New("not found")
+	ErrDuplicateRefID = errors.New("duplicate refID")
 )
 
 // ResolveError is the base of all returned errors.
@@ -29,3 +33,66 @@ func (e *ResolveError) Error() string {
 func (e *ResolveError) Unwrap() error {
 	return e.Err
 }
+
+// CycleError is returned when tables have circular dependencies which cannot be broken automatically.
+type CycleError struct {
+	Path   []TableID   // tables in the cycle, with the first table repeated at the end.
+	Edges  []CycleEdge // the dependency of each table in Path on the next one.
+	Reason string      // why the cycle could not be broken.
+}
+
+// CycleEdge is a dependency of one table on another which is part of a cycle.
+type CycleEdge struct {
+	TableID   TableID
+	DependsOn TableID
+	Explicit  bool             // whether the dependency was added using Data.AddDependencies.
+	Breakable bool             // whether the dependency was added using Data.AddBreakableDependencies.
+	Fields    []CycleEdgeField // the row fields whose values introduced the dependency.
+}
+
+// CycleEdgeField is a row field whose value introduced a dependency.
+type CycleEdgeField struct {
+	InternalID uuid.UUID
+	RefID      RefID
+	FieldName  string
+	Value      ValueDependencies
+}
+
+func (e *CycleError) Error() string {
+	var b strings.Builder
+	b.WriteString("circular dependency between tables cannot be broken")
+	if e.Reason != "" {
+		b.WriteString(" (")
+		b.WriteString(e.Reason)
+		b.WriteString(")")
+	}
+	b.WriteString(": ")
+	for idx, tableID := range e.Path {
+		if idx > 0 {
+			b.WriteString(" -> ")
+		}
+		b.WriteString(tableID.TableID())
+	}
+	for _, edge := range e.Edges {
+		var causes []string
+		if edge.Explicit {
+			causes = append(causes, "explicit dependency")
+		}
+		if edge.Breakable {
+			causes = append(causes, "breakable dependency")
+		}
+		for _, field := range edge.Fields {
+			causes = append(causes, field.String())
+		}
+		fmt.Fprintf(&b, " [%s -> %s: %s]", edge.TableID.TableID(), edge.DependsOn.TableID(), strings.Join(causes, "; "))
+	}
+	return b.String()
+}
+
+func (f CycleEdgeField) String() string {
+	row := f.InternalID.String()
+	if f.RefID != "" {
+		row = string(f.RefID)
+	}
+	return fmt.Sprintf("row '%s' field '%s' (%T)", row, f.FieldName, f.Value)
+}
@@ -0,0 +1,210 @@
+package fixture
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rrgmc/debefix/v2"
+)
+
+// LoadCSV loads the rows of a single table from a CSV file into data. The first row is the header, with the field
+// names optionally followed by a type, like "user_id:int" or "author_id:ref(users.user_id)".
+//
+// The table id is set by [WithLoadTable], or is the base file name without extension (set by [WithLoadFileName],
+// which [LoadFS] does automatically), so "fixtures/countries.csv" loads into the "countries" table.
+//
+// The supported column types are:
+//
+//   - string (default): the cell value.
+//   - int, float, bool: the cell value parsed as int, float64 or bool. Blank cells are nil.
+//   - time: the cell value parsed as RFC3339. Blank cells are nil.
+//   - uuid: a fixed UUID, or a random one generated when resolving if blank ([debefix.ValueUUID] or
+//     [debefix.ValueGenUUID]).
+//   - basetime: the resolve base time plus the cell offset, like "+1h" or "-2d", with the same format and options
+//     as the YAML !basetime tag ([debefix.ValueBaseTimeAdd]).
+//   - resolve, resolve(uuid): a value resolved by the resolve callback, the cell value is ignored
+//     ([debefix.ResolveValueResolve] or [debefix.ResolveValueUUID]).
+//   - refid: sets the row RefID ([debefix.SetValueRefID]). A column named "_refid" has this type by default.
+//   - ref(table.field): the cell is the RefID of a row in the other table, and the value is the field of that row
+//     ([debefix.ValueRefID]). Blank cells are nil.
+//
+// The file is fully parsed before any row is added to data, so a parse error don't leave partial data.
+func LoadCSV(data *debefix.Data, r io.Reader, options ...LoadOption) error {
+	optns := parseLoadOptions(options...)
+	tables := newTableIDResolver(data, optns.tableIDFunc)
+
+	tableID := optns.table
+	if tableID == "" && optns.fileName != "" {
+		tableID = strings.TrimSuffix(path.Base(optns.fileName), path.Ext(optns.fileName))
+	}
+	if tableID == "" {
+		return &ParseError{FileName: optns.fileName, Err: errors.New("table id not set for CSV file")}
+	}
+
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return &ParseError{FileName: optns.fileName, Err: err}
+	}
+
+	var columns []csvColumn
+	for colIdx, h := range header {
+		column, err := parseCSVColumn(tables, h)
+		if err != nil {
+			line, col := cr.FieldPos(colIdx)
+			return &ParseError{FileName: optns.fileName, Line: line, Column: col, Err: err}
+		}
+		columns = append(columns, column)
+	}
+
+	var rows []debefix.MapValues
+	var origins []string
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return &ParseError{FileName: optns.fileName, Err: err}
+		}
+		row := debefix.MapValues{}
+		for colIdx, cell := range record {
+			value, err := columns[colIdx].parse(cell)
+			if err != nil {
+				line, col := cr.FieldPos(colIdx)
+				return &ParseError{FileName: optns.fileName, Line: line, Column: col,
+					Err: fmt.Errorf("field '%s': %w", columns[colIdx].name, err)}
+			}
+			row[columns[colIdx].name] = value
+		}
+		rows = append(rows, row)
+		line, col := cr.FieldPos(0)
+		origins = append(origins, position(optns.fileName, line, col))
+	}
+
+	table := tables.tableID(tableID)
+	if len(rows) == 0 {
+		data.AddDependencies(table)
+	}
+	for rowIdx, row := range rows {
+		data.Add(table, row, debefix.WithDataAddTags(optns.tags...), debefix.WithDataAddOrigin(origins[rowIdx]))
+	}
+
+	return nil
+}
+
+// WithLoadTable sets the table id for file formats that contain a single table, like CSV.
+func WithLoadTable(tableID string) LoadOption {
+	return func(options *loadOptions) {
+		options.table = tableID
+	}
+}
+
+type csvColumn struct {
+	name  string
+	parse func(cell string) (any, error)
+}
+
+// parseCSVColumn parses a "name:type" column header.
+func parseCSVColumn(tables *tableIDResolver, header string) (csvColumn, error) {
+	name, typ, _ := strings.Cut(strings.TrimSpace(header), ":")
+	if name == "" {
+		return csvColumn{}, fmt.Errorf("invalid blank column name in header '%s'", header)
+	}
+	if typ == "" && name == "_refid" {
+		typ = "refid"
+	}
+
+	ret := csvColumn{name: name}
+	switch {
+	case typ == "" || typ == "string":
+		ret.parse = func(cell string) (any, error) {
+			return cell, nil
+		}
+	case typ == "int":
+		ret.parse = csvParseNullable(func(cell string) (any, error) {
+			return strconv.Atoi(cell)
+		})
+	case typ == "float":
+		ret.parse = csvParseNullable(func(cell string) (any, error) {
+			return strconv.ParseFloat(cell, 64)
+		})
+	case typ == "bool":
+		ret.parse = csvParseNullable(func(cell string) (any, error) {
+			return strconv.ParseBool(cell)
+		})
+	case typ == "time":
+		ret.parse = csvParseNullable(func(cell string) (any, error) {
+			return time.Parse(time.RFC3339, cell)
+		})
+	case typ == "uuid":
+		ret.parse = func(cell string) (any, error) {
+			return parseUUID(cell)
+		}
+	case typ == "basetime":
+		ret.parse = func(cell string) (any, error) {
+			return parseBaseTimeAdd(cell)
+		}
+	case typ == "resolve" || strings.HasPrefix(typ, "resolve("):
+		var resolveType string
+		if typ != "resolve" {
+			var ok bool
+			resolveType, ok = csvTypeArg(typ, "resolve")
+			if !ok {
+				return csvColumn{}, fmt.Errorf("invalid column type '%s'", typ)
+			}
+		}
+		if _, err := parseResolve(resolveType); err != nil {
+			return csvColumn{}, err
+		}
+		ret.parse = func(cell string) (any, error) {
+			return parseResolve(resolveType)
+		}
+	case typ == "refid":
+		ret.parse = func(cell string) (any, error) {
+			return parseRefID(cell)
+		}
+	case strings.HasPrefix(typ, "ref("):
+		arg, ok := csvTypeArg(typ, "ref")
+		sep := strings.LastIndex(arg, ".")
+		if !ok || sep <= 0 || sep == len(arg)-1 {
+			return csvColumn{}, fmt.Errorf("invalid column type '%s', expected 'ref(table.field)'", typ)
+		}
+		tableID, fieldName := tables.tableID(arg[:sep]), arg[sep+1:]
+		ret.parse = csvParseNullable(func(cell string) (any, error) {
+			return debefix.ValueRefID(tableID, debefix.RefID(cell), fieldName), nil
+		})
+	default:
+		return csvColumn{}, fmt.Errorf("unknown column type '%s'", typ)
+	}
+	return ret, nil
+}
+
+// csvTypeArg returns the argument of a "name(arg)" type.
+func csvTypeArg(typ string, name string) (string, bool) {
+	if !strings.HasPrefix(typ, name+"(") || !strings.HasSuffix(typ, ")") {
+		return "", false
+	}
+	return typ[len(name)+1 : len(typ)-1], true
+}
+
+// csvParseNullable returns nil for blank cells, or calls the parse function.
+func csvParseNullable(parse func(cell string) (any, error)) func(cell string) (any, error) {
+	return func(cell string) (any, error) {
+		if cell == "" {
+			return nil, nil
+		}
+		return parse(cell)
+	}
+}
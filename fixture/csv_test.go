@@ -0,0 +1,107 @@
+package fixture
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/rrgmc/debefix/v2"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestLoadCSV(t *testing.T) {
+	data := debefix.NewData()
+
+	err := LoadCSV(data, strings.NewReader(`_refid,tag_id:resolve,name,weight:float,active:bool,created_at:basetime,author:ref(public.users.user_id),order:int
+go,,Go,1.5,true,+1h,johndoe,1
+cpp,,C++,,false,-2d,,
+`), WithLoadFileName("fixtures/tags.csv"))
+	assert.NilError(t, err)
+
+	tagsTable, ok := data.Tables["tags"]
+	assert.Assert(t, ok, "tags table not found")
+	assert.Assert(t, is.Len(tagsTable.Rows, 2))
+	assert.Equal(t, debefix.RefID("go"), tagsTable.Rows[0].RefID)
+	assert.Equal(t, debefix.RefID("cpp"), tagsTable.Rows[1].RefID)
+	assert.DeepEqual(t, []string{"public.users"}, tableIDs(tagsTable.Depends))
+
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{
+			"tag_id":     debefix.ResolveValueResolve(),
+			"name":       "Go",
+			"weight":     1.5,
+			"active":     true,
+			"created_at": debefix.ValueBaseTimeAdd(debefix.WithAddHours(1)),
+			"author":     debefix.ValueRefID(debefix.TableName("public.users"), "johndoe", "user_id"),
+			"order":      1,
+		},
+		{
+			"tag_id":     debefix.ResolveValueResolve(),
+			"name":       "C++",
+			"weight":     nil,
+			"active":     false,
+			"created_at": debefix.ValueBaseTimeAdd(debefix.WithAddDays(-2)),
+			"author":     nil,
+			"order":      nil,
+		},
+	}, tagsTable.Rows)
+}
+
+func TestLoadCSVTable(t *testing.T) {
+	data := debefix.NewData()
+
+	err := LoadCSV(data, strings.NewReader("country_id:int,name\n1,Brazil\n"), WithLoadTable("countries"))
+	assert.NilError(t, err)
+
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{"country_id": 1, "name": "Brazil"},
+	}, data.Tables["countries"].Rows)
+}
+
+func TestLoadCSVInvalidValue(t *testing.T) {
+	err := LoadCSV(debefix.NewData(), strings.NewReader("country_id:int,name\n1,Brazil\nx,Chile\n"),
+		WithLoadFileName("countries.csv"))
+	var perr *ParseError
+	assert.Assert(t, errors.As(err, &perr))
+	assert.Equal(t, 3, perr.Line)
+	assert.ErrorContains(t, err, "field 'country_id'")
+
+	err = LoadCSV(debefix.NewData(), strings.NewReader("_refid,country_id:int\n  ,1\n"),
+		WithLoadFileName("countries.csv"))
+	assert.ErrorContains(t, err, "RefID can't be blank")
+}
+
+func TestLoadCSVBaseTime(t *testing.T) {
+	data := debefix.NewData()
+	err := LoadCSV(data, strings.NewReader("event_id:int,starts_at:basetime\n1,1mo truncate=hour tz=UTC\n"),
+		WithLoadTable("events"))
+	assert.NilError(t, err)
+
+	value, ok := data.Tables["events"].Rows[0].Values.GetOrNil("starts_at").(debefix.ValueBaseTimeAddData)
+	assert.Assert(t, ok)
+	assert.Equal(t, 1, value.AddMonths)
+	assert.Equal(t, debefix.TimeTruncateHour, value.Truncate)
+	assert.Equal(t, time.UTC, value.Location)
+}
+
+func TestLoadCSVFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"countries.csv": &fstest.MapFile{Data: []byte("_refid,country_id:int\nbr,1\n")},
+		"users.yaml": &fstest.MapFile{Data: []byte(`
+users:
+  rows:
+    - user_id: 1
+      country_id: !ref countries:br:country_id
+`)},
+	}
+
+	data := debefix.NewData()
+	err := LoadFS(data, fsys)
+	assert.NilError(t, err)
+
+	assert.Assert(t, is.Len(data.Tables["countries"].Rows, 1))
+	assert.Assert(t, is.Len(data.Tables["users"].Rows, 1))
+}
@@ -0,0 +1,164 @@
+// Package fixture loads debefix Data from fixture files.
+package fixture
+
+import (
+	"fmt"
+
+	"github.com/rrgmc/debefix/v2"
+)
+
+// TableIDFunc creates a [debefix.TableID] from the table id used in fixture files, and an optional table name.
+type TableIDFunc func(id string, tableName string) debefix.TableID
+
+// DefaultTableID returns a [debefix.TableName] if tableName is blank or equal to id, otherwise a
+// [debefix.TableNameID].
+func DefaultTableID(id string, tableName string) debefix.TableID {
+	if tableName == "" || tableName == id {
+		return debefix.TableName(id)
+	}
+	return debefix.NewTableNameID(id, tableName)
+}
+
+// ParseError is returned when a fixture file could not be parsed.
+type ParseError struct {
+	FileName string // file name, if known.
+	Line     int    // line of the error, if known.
+	Column   int    // column of the error, if known.
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	pos := position(e.FileName, e.Line, e.Column)
+	if pos == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", pos, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// position returns a file position like "file.yaml:10:3", omitting the parts which are not known.
+func position(fileName string, line, column int) string {
+	pos := fileName
+	if line > 0 {
+		if pos != "" {
+			pos += ":"
+		}
+		pos += fmt.Sprintf("%d:%d", line, column)
+	}
+	return pos
+}
+
+// LoadOption are options for the fixture load functions.
+type LoadOption func(options *loadOptions)
+
+// WithLoadTableID sets the function used to create table ids. The default is [DefaultTableID].
+func WithLoadTableID(f TableIDFunc) LoadOption {
+	return func(options *loadOptions) {
+		options.tableIDFunc = f
+	}
+}
+
+// WithLoadFileName sets the file name reported in errors.
+func WithLoadFileName(fileName string) LoadOption {
+	return func(options *loadOptions) {
+		options.fileName = fileName
+	}
+}
+
+// WithLoadTags adds tags to all loaded rows, which can be used to filter rows using
+// [debefix.WithResolveOptionTagFilter].
+func WithLoadTags(tags ...string) LoadOption {
+	return func(options *loadOptions) {
+		options.tags = append(options.tags, tags...)
+	}
+}
+
+type loadOptions struct {
+	tableIDFunc TableIDFunc
+	fileName    string
+	tags        []string
+	fileLoaders map[string]FileLoader
+	table       string
+}
+
+func parseLoadOptions(options ...LoadOption) loadOptions {
+	optns := loadOptions{
+		tableIDFunc: DefaultTableID,
+	}
+	for _, opt := range options {
+		opt(&optns)
+	}
+	return optns
+}
+
+// tableConfig is the optional "config" of a table in the YAML and JSON formats.
+type tableConfig struct {
+	TableName        string   `json:"table_name,omitempty" yaml:"table_name"`
+	Depends          []string `json:"depends,omitempty" yaml:"depends"`
+	BreakableDepends []string `json:"breakable_depends,omitempty" yaml:"breakable_depends"`
+	KeyFields        []string `json:"key_fields,omitempty" yaml:"key_fields"`
+	Tags             []string `json:"tags,omitempty" yaml:"tags"`
+}
+
+// newTableConfig returns the config of a table of data, or nil if it has nothing to be written.
+func newTableConfig(tableID string, table *debefix.Table) *tableConfig {
+	ret := &tableConfig{
+		KeyFields: table.KeyFields,
+		Tags:      table.Tags,
+	}
+	if table.TableID.TableName() != tableID {
+		ret.TableName = table.TableID.TableName()
+	}
+	for _, dep := range table.Depends {
+		ret.Depends = append(ret.Depends, dep.TableID())
+	}
+	for _, dep := range table.BreakableDepends {
+		ret.BreakableDepends = append(ret.BreakableDepends, dep.TableID())
+	}
+	if ret.TableName == "" && len(ret.Depends) == 0 && len(ret.BreakableDepends) == 0 && len(ret.KeyFields) == 0 &&
+		len(ret.Tags) == 0 {
+		return nil
+	}
+	return ret
+}
+
+// loadTableConfig is a table config with the table ids resolved, to be set on data after the file was parsed.
+type loadTableConfig struct {
+	depends          []debefix.TableID
+	breakableDepends []debefix.TableID
+	keyFields        []string
+	tags             []string
+}
+
+func newLoadTableConfig(tables *tableIDResolver, config tableConfig) loadTableConfig {
+	ret := loadTableConfig{
+		keyFields: config.KeyFields,
+		tags:      config.Tags,
+	}
+	for _, dep := range config.Depends {
+		ret.depends = append(ret.depends, tables.tableID(dep))
+	}
+	for _, dep := range config.BreakableDepends {
+		ret.breakableDepends = append(ret.breakableDepends, tables.tableID(dep))
+	}
+	return ret
+}
+
+// apply sets the table config on data. Tables without rows are always added, so they are known by data.
+func (c loadTableConfig) apply(data *debefix.Data, tableID debefix.TableID, hasRows bool) {
+	if len(c.depends) > 0 || !hasRows {
+		data.AddDependencies(tableID, c.depends...)
+	}
+	if len(c.breakableDepends) > 0 {
+		data.AddBreakableDependencies(tableID, c.breakableDepends...)
+	}
+	if len(c.keyFields) > 0 {
+		data.SetTableKeyFields(tableID, c.keyFields...)
+	}
+	if len(c.tags) > 0 {
+		data.SetTableTags(tableID, c.tags...)
+	}
+}
@@ -0,0 +1,81 @@
+package fixture
+
+import (
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/rrgmc/debefix/v2"
+)
+
+// FileLoader loads a fixture file into data. [LoadYAML] is an example of a FileLoader.
+type FileLoader func(data *debefix.Data, r io.Reader, options ...LoadOption) error
+
+// DefaultFileLoaders returns the default file loaders by file extension.
+func DefaultFileLoaders() map[string]FileLoader {
+	return map[string]FileLoader{
+		".yaml": LoadYAML,
+		".yml":  LoadYAML,
+		".json": LoadJSON,
+		".csv":  LoadCSV,
+	}
+}
+
+// LoadFS loads all fixture files from fsys into data, selecting the loader by the file extension.
+// Files with unknown extensions are ignored.
+//
+// Files are loaded in path order, walking the directories depth-first in lexical order (the same order as
+// [fs.WalkDir]), so "a/b.yaml" is loaded before "a/c.yaml", which is loaded before "b.yaml".
+// As rows are added in the order they are loaded, the rows of tables split into multiple files keep a stable order.
+//
+// To load from an embedded directory, use [fs.Sub] or pass the [embed.FS] directly:
+//
+//	//go:embed fixtures
+//	var fixtures embed.FS
+//
+//	err := fixture.LoadFS(data, fixtures)
+func LoadFS(data *debefix.Data, fsys fs.FS, options ...LoadOption) error {
+	optns := parseLoadOptions(options...)
+	loaders := optns.fileLoaders
+	if loaders == nil {
+		loaders = DefaultFileLoaders()
+	}
+
+	return fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		loader, ok := loaders[strings.ToLower(path.Ext(filePath))]
+		if !ok {
+			return nil
+		}
+
+		f, err := fsys.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		fileOptions := append(append([]LoadOption{}, options...), WithLoadFileName(filePath))
+		return loader(data, f, fileOptions...)
+	})
+}
+
+// LoadDir loads all fixture files from a directory into data. See [LoadFS] for the loading order.
+func LoadDir(data *debefix.Data, dir string, options ...LoadOption) error {
+	return LoadFS(data, os.DirFS(dir), options...)
+}
+
+// WithLoadFileLoaders sets the file loaders used by [LoadFS], by file extension (including the dot).
+// The default is [DefaultFileLoaders].
+func WithLoadFileLoaders(loaders map[string]FileLoader) LoadOption {
+	return func(options *loadOptions) {
+		options.fileLoaders = maps.Clone(loaders)
+	}
+}
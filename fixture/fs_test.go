@@ -0,0 +1,55 @@
+package fixture
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/rrgmc/debefix/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"b.yaml": &fstest.MapFile{Data: []byte(`
+tags:
+  rows:
+    - tag_id: 4
+`)},
+		"a/z.yml": &fstest.MapFile{Data: []byte(`
+tags:
+  rows:
+    - tag_id: 2
+    - tag_id: 3
+`)},
+		"a/a.yaml": &fstest.MapFile{Data: []byte(`
+tags:
+  rows:
+    - tag_id: 1
+`)},
+		"README.md": &fstest.MapFile{Data: []byte(`not a fixture`)},
+	}
+
+	data := debefix.NewData()
+	err := LoadFS(data, fsys)
+	assert.NilError(t, err)
+
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{"tag_id": 1},
+		{"tag_id": 2},
+		{"tag_id": 3},
+		{"tag_id": 4},
+	}, data.Tables["tags"].Rows)
+}
+
+func TestLoadFSErrorFileName(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dir/tags.yaml": &fstest.MapFile{Data: []byte(`
+tags:
+  rows:
+    - tag_id: !ref invalid
+`)},
+	}
+
+	err := LoadFS(debefix.NewData(), fsys)
+	assert.ErrorContains(t, err, "dir/tags.yaml:4:15: ")
+}
@@ -0,0 +1,191 @@
+package fixture
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rrgmc/debefix/v2"
+)
+
+// WriteGo writes Go source code of a package which declares a function returning a new [debefix.Data] with the same
+// tables and rows as data. The function name is set by [WithGoFuncName], and defaults to "NewData".
+// Tables are written sorted by table id. Updates are not written.
+// Values which have no Go representation returns an error.
+func WriteGo(w io.Writer, data *debefix.Data, packageName string, options ...GoOption) error {
+	optns := goOptions{
+		funcName: "NewData",
+	}
+	for _, opt := range options {
+		opt(&optns)
+	}
+
+	g := &goWriter{}
+	for _, tableID := range slices.Sorted(maps.Keys(data.Tables)) {
+		table := data.Tables[tableID]
+		tableExpr := goTableID(table.TableID)
+		if len(table.Depends) > 0 || len(table.Rows) == 0 {
+			g.printf("data.AddDependencies(%s", tableExpr)
+			for _, dep := range table.Depends {
+				g.printf(", %s", goTableID(dep))
+			}
+			g.printf(")\n")
+		}
+		if len(table.BreakableDepends) > 0 {
+			g.printf("data.AddBreakableDependencies(%s", tableExpr)
+			for _, dep := range table.BreakableDepends {
+				g.printf(", %s", goTableID(dep))
+			}
+			g.printf(")\n")
+		}
+		if len(table.Tags) > 0 {
+			g.printf("data.SetTableTags(%s", tableExpr)
+			for _, tag := range table.Tags {
+				g.printf(", %s", strconv.Quote(tag))
+			}
+			g.printf(")\n")
+		}
+		if len(table.KeyFields) > 0 {
+			g.printf("data.SetTableKeyFields(%s", tableExpr)
+			for _, keyField := range table.KeyFields {
+				g.printf(", %s", strconv.Quote(keyField))
+			}
+			g.printf(")\n")
+		}
+		if len(table.Rows) == 0 {
+			continue
+		}
+		g.printf("data.AddValues(%s,\n", tableExpr)
+		for _, row := range table.Rows {
+			g.printf("debefix.MapValues{\n")
+			if row.RefID != "" {
+				g.printf("%q: debefix.SetValueRefID(%q),\n", "_refid", row.RefID)
+			}
+			var fieldNames []string
+			for fieldName := range row.Values.All {
+				fieldNames = append(fieldNames, fieldName)
+			}
+			slices.Sort(fieldNames)
+			for _, fieldName := range fieldNames {
+				value, err := g.value(row.Values.GetOrNil(fieldName))
+				if err != nil {
+					return fmt.Errorf("table '%s' field '%s': %w", tableID, fieldName, err)
+				}
+				g.printf("%q: %s,\n", fieldName, value)
+			}
+			g.printf("},\n")
+		}
+		g.printf(")\n")
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by debefix. DO NOT EDIT.\n\npackage %s\n\n", packageName)
+	src.WriteString("import (\n")
+	if g.usesTime {
+		src.WriteString("\"time\"\n\n")
+	}
+	if g.usesUUID {
+		src.WriteString("\"github.com/google/uuid\"\n")
+	}
+	src.WriteString("\"github.com/rrgmc/debefix/v2\"\n)\n\n")
+	fmt.Fprintf(&src, "func %s() *debefix.Data {\ndata := debefix.NewData()\n", optns.funcName)
+	src.Write(g.buf.Bytes())
+	src.WriteString("return data\n}\n")
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("error formatting generated code: %w", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// GoOption are options for [WriteGo].
+type GoOption func(options *goOptions)
+
+// WithGoFuncName sets the name of the generated function.
+func WithGoFuncName(funcName string) GoOption {
+	return func(options *goOptions) {
+		options.funcName = funcName
+	}
+}
+
+type goOptions struct {
+	funcName string
+}
+
+// goWriter writes the body of the generated function, and tracks the imports it needs.
+type goWriter struct {
+	buf      bytes.Buffer
+	usesTime bool
+	usesUUID bool
+}
+
+func (g *goWriter) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// value returns the Go expression of a field value.
+func (g *goWriter) value(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "nil", nil
+	case string:
+		return strconv.Quote(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%T(%d)", v, v), nil
+	case float32:
+		return fmt.Sprintf("float32(%s)", strconv.FormatFloat(float64(v), 'g', -1, 32)), nil
+	case float64:
+		return fmt.Sprintf("float64(%s)", strconv.FormatFloat(v, 'g', -1, 64)), nil
+	case []byte:
+		return fmt.Sprintf("[]byte(%s)", strconv.Quote(string(v))), nil
+	case time.Time:
+		g.usesTime = true
+		v = v.UTC()
+		return fmt.Sprintf("time.Date(%d, %d, %d, %d, %d, %d, %d, time.UTC)", v.Year(), v.Month(), v.Day(),
+			v.Hour(), v.Minute(), v.Second(), v.Nanosecond()), nil
+	case debefix.ValueRefIDData:
+		return fmt.Sprintf("debefix.ValueRefID(%s, %q, %q)", goTableID(v.TableID), v.RefID, v.FieldName), nil
+	case debefix.ResolveValueResolveData:
+		return "debefix.ResolveValueResolve()", nil
+	case debefix.ResolveValueUUIDData:
+		return "debefix.ResolveValueUUID()", nil
+	case debefix.ValueUUIDData:
+		g.usesUUID = true
+		return fmt.Sprintf("debefix.ValueUUID(uuid.MustParse(%q))", v.Value.String()), nil
+	case debefix.ValueGenUUIDData:
+		return "debefix.ValueGenUUID()", nil
+	case debefix.ValueFieldValueData:
+		return fmt.Sprintf("debefix.ValueFieldValue(%q)", v.FieldName), nil
+	case debefix.ValueFormatData:
+		ret := fmt.Sprintf("debefix.ValueFormat(%q", v.Format)
+		for argIdx, arg := range v.Args {
+			argValue, err := g.value(arg)
+			if err != nil {
+				return "", fmt.Errorf("argument %d: %w", argIdx, err)
+			}
+			ret += ", " + argValue
+		}
+		return ret + ")", nil
+	default:
+		return "", fmt.Errorf("value of type '%T' cannot be written as Go code", value)
+	}
+}
+
+// goTableID returns the Go expression of a table id.
+func goTableID(tableID debefix.TableID) string {
+	if tableID.TableID() == tableID.TableName() {
+		return fmt.Sprintf("debefix.TableName(%q)", tableID.TableID())
+	}
+	return fmt.Sprintf("debefix.NewTableNameID(%q, %q)", tableID.TableID(), tableID.TableName())
+}
@@ -0,0 +1,88 @@
+package fixture
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rrgmc/debefix/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteGo(t *testing.T) {
+	data := debefix.NewData()
+	data.SetTableKeyFields(debefix.TableName("tags"), "tag_id")
+	data.SetTableTags(debefix.TableName("tags"), "blog")
+	data.AddBreakableDependencies(debefix.NewTableNameID("posts", "public.posts"), debefix.TableName("tags"))
+	data.AddValues(debefix.TableName("tags"),
+		debefix.MapValues{
+			"_refid":     debefix.SetValueRefID("go"),
+			"tag_id":     debefix.ResolveValueResolve(),
+			"name":       "Go",
+			"created_at": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+	)
+	data.AddValues(debefix.NewTableNameID("posts", "public.posts"),
+		debefix.MapValues{
+			"post_id": debefix.ValueUUID(uuid.MustParse("c06a1f3e-3578-4b56-bf51-9fb949ae5dbf")),
+			"tag_id":  debefix.ValueRefID(debefix.TableName("tags"), "go", "tag_id"),
+			"title":   debefix.ValueFormat("Post about %s", debefix.ValueRefID(debefix.TableName("tags"), "go", "name")),
+			"score":   1.5,
+			"views":   int64(10),
+			"draft":   nil,
+		},
+	)
+
+	var buf bytes.Buffer
+	err := WriteGo(&buf, data, "fixtures", WithGoFuncName("NewTestData"))
+	assert.NilError(t, err)
+	assert.Equal(t, `// Code generated by debefix. DO NOT EDIT.
+
+package fixtures
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rrgmc/debefix/v2"
+)
+
+func NewTestData() *debefix.Data {
+	data := debefix.NewData()
+	data.AddDependencies(debefix.NewTableNameID("posts", "public.posts"), debefix.TableName("tags"))
+	data.AddBreakableDependencies(debefix.NewTableNameID("posts", "public.posts"), debefix.TableName("tags"))
+	data.AddValues(debefix.NewTableNameID("posts", "public.posts"),
+		debefix.MapValues{
+			"draft":   nil,
+			"post_id": debefix.ValueUUID(uuid.MustParse("c06a1f3e-3578-4b56-bf51-9fb949ae5dbf")),
+			"score":   float64(1.5),
+			"tag_id":  debefix.ValueRefID(debefix.TableName("tags"), "go", "tag_id"),
+			"title":   debefix.ValueFormat("Post about %s", debefix.ValueRefID(debefix.TableName("tags"), "go", "name")),
+			"views":   int64(10),
+		},
+	)
+	data.SetTableTags(debefix.TableName("tags"), "blog")
+	data.SetTableKeyFields(debefix.TableName("tags"), "tag_id")
+	data.AddValues(debefix.TableName("tags"),
+		debefix.MapValues{
+			"_refid":     debefix.SetValueRefID("go"),
+			"created_at": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			"name":       "Go",
+			"tag_id":     debefix.ResolveValueResolve(),
+		},
+	)
+	return data
+}
+`, buf.String())
+}
+
+func TestWriteGoUnsupportedValue(t *testing.T) {
+	data := debefix.NewData()
+	data.Add(debefix.TableName("tags"), debefix.MapValues{
+		"tag_id": debefix.ValueBaseTimeAdd(),
+	})
+
+	err := WriteGo(&bytes.Buffer{}, data, "fixtures")
+	assert.ErrorContains(t, err, "cannot be written as Go code")
+}
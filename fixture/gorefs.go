@@ -0,0 +1,143 @@
+package fixture
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/rrgmc/debefix/v2"
+)
+
+// WriteGoRefs writes Go source code of a package which declares a variable for each table of data, which implements
+// [debefix.TableID] and has a field for each row with a RefID, with a method for each row field which returns a
+// [debefix.ValueRefID] referencing it. Identifiers are created from the table ids, RefIDs and field names
+// converted to camel case, so for a "users" table with a "john_doe" row with a "user_id" field:
+//
+//	debefix.ValueRefID(debefix.TableName("users"), "john_doe", "user_id")
+//
+// can be written as:
+//
+//	fixtures.Users.JohnDoe.UserID()
+//
+// The fields of each table are all the fields set by any of its rows. An error is returned if two names would
+// generate the same identifier.
+//
+// The debefix-gen command calls it for fixture files, and can be used with go generate:
+//
+//	//go:generate go run github.com/rrgmc/debefix/v2/cmd/debefix-gen -o fixtures_refs.go ./fixtures
+func WriteGoRefs(w io.Writer, data *debefix.Data, packageName string) error {
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by debefix. DO NOT EDIT.\n\npackage %s\n\n", packageName)
+	src.WriteString("import \"github.com/rrgmc/debefix/v2\"\n")
+
+	tableNames := map[string]string{}
+	for _, tableID := range slices.Sorted(maps.Keys(data.Tables)) {
+		table := data.Tables[tableID]
+		name := goIdentifier(tableID)
+		if other, ok := tableNames[name]; ok {
+			return fmt.Errorf("tables '%s' and '%s' generate the same identifier '%s'", other, tableID, name)
+		}
+		tableNames[name] = tableID
+		tableType := goUnexported(name) + "Table"
+		rowType := goUnexported(name) + "Row"
+
+		// rows with RefID, which are table struct fields.
+		rowNames := map[string]string{}
+		var refIDs []string
+		fieldNames := map[string]bool{}
+		for _, row := range table.Rows {
+			for fieldName := range row.Values.All {
+				fieldNames[fieldName] = true
+			}
+			if row.RefID == "" || slices.Contains(refIDs, string(row.RefID)) {
+				continue
+			}
+			rowName := goIdentifier(string(row.RefID))
+			if rowName == "TableID" || rowName == "TableName" {
+				return fmt.Errorf("table '%s' RefID '%s' generates the reserved identifier '%s'", tableID, row.RefID,
+					rowName)
+			}
+			if other, ok := rowNames[rowName]; ok {
+				return fmt.Errorf("table '%s' RefIDs '%s' and '%s' generate the same identifier '%s'", tableID, other,
+					row.RefID, rowName)
+			}
+			rowNames[rowName] = string(row.RefID)
+			refIDs = append(refIDs, string(row.RefID))
+		}
+
+		fmt.Fprintf(&src, "\n// %s is the %q table.\n", name, tableID)
+		fmt.Fprintf(&src, "var %s = %s{\ntableID: %s,\n", name, tableType, goTableID(table.TableID))
+		for _, refID := range refIDs {
+			fmt.Fprintf(&src, "%s: %s{tableID: %s, refID: %q},\n", goIdentifier(refID), rowType,
+				goTableID(table.TableID), refID)
+		}
+		src.WriteString("}\n")
+
+		fmt.Fprintf(&src, "\ntype %s struct {\ntableID debefix.TableID\n", tableType)
+		for _, refID := range refIDs {
+			fmt.Fprintf(&src, "%s %s\n", goIdentifier(refID), rowType)
+		}
+		src.WriteString("}\n")
+		fmt.Fprintf(&src, "\nfunc (t %s) TableID() string {\nreturn t.tableID.TableID()\n}\n", tableType)
+		fmt.Fprintf(&src, "\nfunc (t %s) TableName() string {\nreturn t.tableID.TableName()\n}\n", tableType)
+
+		fmt.Fprintf(&src, "\ntype %s struct {\ntableID debefix.TableID\nrefID debefix.RefID\n}\n", rowType)
+		methodNames := map[string]string{}
+		for _, fieldName := range slices.Sorted(maps.Keys(fieldNames)) {
+			methodName := goIdentifier(fieldName)
+			if other, ok := methodNames[methodName]; ok {
+				return fmt.Errorf("table '%s' fields '%s' and '%s' generate the same identifier '%s'", tableID, other,
+					fieldName, methodName)
+			}
+			methodNames[methodName] = fieldName
+			fmt.Fprintf(&src, "\n// %s returns a reference to the %q field of the row.\n", methodName, fieldName)
+			fmt.Fprintf(&src, "func (r %s) %s() debefix.ValueRefIDData {\nreturn debefix.ValueRefID(r.tableID, r.refID, %q)\n}\n",
+				rowType, methodName, fieldName)
+		}
+	}
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("error formatting generated code: %w", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// goIdentifier converts a name to an exported Go identifier in camel case, like "public.user_tags" to
+// "PublicUserTags", with common initialisms in upper case, like "user_id" to "UserID". Names which don't start with a
+// letter are prefixed with "X".
+func goIdentifier(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if slices.Contains(goInitialisms, strings.ToUpper(word)) {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	ret := b.String()
+	if ret == "" || !unicode.IsLetter([]rune(ret)[0]) {
+		ret = "X" + ret
+	}
+	return ret
+}
+
+// goInitialisms are the initialisms which are written in upper case in Go identifiers.
+var goInitialisms = []string{"API", "HTML", "HTTP", "ID", "IP", "JSON", "SQL", "URI", "URL", "UUID", "XML"}
+
+// goUnexported returns the identifier with its first letter in lower case.
+func goUnexported(name string) string {
+	r := []rune(name)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
@@ -0,0 +1,124 @@
+package fixture
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rrgmc/debefix/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteGoRefs(t *testing.T) {
+	data := debefix.NewData()
+	err := LoadYAML(data, strings.NewReader(`
+users:
+  config:
+    table_name: public.users
+  rows:
+    - user_id: !resolve
+      _refid: !refid john_doe
+      name: John Doe
+    - user_id: !resolve
+      _refid: !refid janeDoe
+      email: jane@example.com
+    - user_id: 3
+posts:
+  rows:
+    - post_id: 1
+      user_id: !ref users:john_doe:user_id
+`))
+	assert.NilError(t, err)
+
+	var buf bytes.Buffer
+	err = WriteGoRefs(&buf, data, "fixtures")
+	assert.NilError(t, err)
+	assert.Equal(t, `// Code generated by debefix. DO NOT EDIT.
+
+package fixtures
+
+import "github.com/rrgmc/debefix/v2"
+
+// Posts is the "posts" table.
+var Posts = postsTable{
+	tableID: debefix.TableName("posts"),
+}
+
+type postsTable struct {
+	tableID debefix.TableID
+}
+
+func (t postsTable) TableID() string {
+	return t.tableID.TableID()
+}
+
+func (t postsTable) TableName() string {
+	return t.tableID.TableName()
+}
+
+type postsRow struct {
+	tableID debefix.TableID
+	refID   debefix.RefID
+}
+
+// PostID returns a reference to the "post_id" field of the row.
+func (r postsRow) PostID() debefix.ValueRefIDData {
+	return debefix.ValueRefID(r.tableID, r.refID, "post_id")
+}
+
+// UserID returns a reference to the "user_id" field of the row.
+func (r postsRow) UserID() debefix.ValueRefIDData {
+	return debefix.ValueRefID(r.tableID, r.refID, "user_id")
+}
+
+// Users is the "users" table.
+var Users = usersTable{
+	tableID: debefix.NewTableNameID("users", "public.users"),
+	JohnDoe: usersRow{tableID: debefix.NewTableNameID("users", "public.users"), refID: "john_doe"},
+	JaneDoe: usersRow{tableID: debefix.NewTableNameID("users", "public.users"), refID: "janeDoe"},
+}
+
+type usersTable struct {
+	tableID debefix.TableID
+	JohnDoe usersRow
+	JaneDoe usersRow
+}
+
+func (t usersTable) TableID() string {
+	return t.tableID.TableID()
+}
+
+func (t usersTable) TableName() string {
+	return t.tableID.TableName()
+}
+
+type usersRow struct {
+	tableID debefix.TableID
+	refID   debefix.RefID
+}
+
+// Email returns a reference to the "email" field of the row.
+func (r usersRow) Email() debefix.ValueRefIDData {
+	return debefix.ValueRefID(r.tableID, r.refID, "email")
+}
+
+// Name returns a reference to the "name" field of the row.
+func (r usersRow) Name() debefix.ValueRefIDData {
+	return debefix.ValueRefID(r.tableID, r.refID, "name")
+}
+
+// UserID returns a reference to the "user_id" field of the row.
+func (r usersRow) UserID() debefix.ValueRefIDData {
+	return debefix.ValueRefID(r.tableID, r.refID, "user_id")
+}
+`, buf.String())
+}
+
+func TestWriteGoRefsDuplicateIdentifier(t *testing.T) {
+	data := debefix.NewData()
+	data.Add(debefix.TableName("users"), debefix.MapValues{"_refid": debefix.SetValueRefID("john_doe")})
+	data.Add(debefix.TableName("users"), debefix.MapValues{"_refid": debefix.SetValueRefID("john-doe")})
+
+	err := WriteGoRefs(&bytes.Buffer{}, data, "fixtures")
+	assert.Error(t, err, "table 'users' RefIDs 'john_doe' and 'john-doe' generate the same identifier 'JohnDoe'")
+}
@@ -0,0 +1,429 @@
+package fixture
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rrgmc/debefix/v2"
+)
+
+// JSONSchema is the JSON Schema of the JSON fixture format, which can be used by editors to validate fixture files.
+//
+//go:embed schema.json
+var JSONSchema []byte
+
+// LoadJSON loads a JSON fixture into data. It has the same structure as the YAML format (see [LoadYAML]), with
+// debefix values encoded as objects with a single "$"-prefixed key:
+//
+//	{
+//	  "users": {
+//	    "config": {"table_name": "public.users", "depends": ["countries"], "key_fields": ["user_id"]},
+//	    "rows": [
+//	      {
+//	        "user_id": {"$resolve": {}},
+//	        "_refid": {"$refid": "johndoe"},
+//	        "name": "John Doe",
+//	        "created_at": {"$basetime": {"hours": 1}}
+//	      }
+//	    ]
+//	  },
+//	  "posts": {
+//	    "rows": [
+//	      {
+//	        "post_id": {"$uuid": ""},
+//	        "user_id": {"$ref": {"table": "users", "refid": "johndoe", "field": "user_id"}},
+//	        "title": {"$format": {"format": "Post by %s", "args": [{"$ref": {"table": "users", "refid": "johndoe", "field": "name"}}]}}
+//	      }
+//	    ]
+//	  }
+//	}
+//
+// The supported values are:
+//
+//   - {"$refid": "<refid>"}: sets the row RefID ([debefix.SetValueRefID]).
+//   - {"$ref": {"table": "<table>", "refid": "<refid>", "field": "<field>"}}: a field of another row
+//     ([debefix.ValueRefID]).
+//   - {"$resolve": {"type": "uuid"}}: a value resolved by the resolve callback, "type" is optional
+//     ([debefix.ResolveValueResolve] or [debefix.ResolveValueUUID]).
+//   - {"$basetime": {"years": 0, "months": 0, "days": 0, "hours": 0, "minutes": 0, "seconds": 0, "truncate": "day",
+//     "time_of_day": "15:04:05", "timezone": "America/Sao_Paulo"}}: the resolve base time plus an offset, all fields
+//     are optional, "truncate" can be "day" or "hour" ([debefix.ValueBaseTimeAdd]).
+//   - {"$uuid": "<uuid>"}: a fixed UUID, or a random one generated when resolving if blank ([debefix.ValueUUID] or
+//     [debefix.ValueGenUUID]).
+//   - {"$format": {"format": "<format>", "args": [...]}}: formats a string using other values ([debefix.ValueFormat]).
+//   - {"$field": "<field>"}: the value of another field of the same row ([debefix.ValueFieldValue]).
+//
+// The file format is described by [JSONSchema]. A root "$schema" key, which editors use to find the schema, is
+// ignored.
+// The file is fully parsed before any row is added to data, so a parse error don't leave partial data.
+func LoadJSON(data *debefix.Data, r io.Reader, options ...LoadOption) error {
+	optns := parseLoadOptions(options...)
+	tables := newTableIDResolver(data, optns.tableIDFunc)
+
+	var rawDoc map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&rawDoc); err != nil && err != io.EOF {
+		return &ParseError{FileName: optns.fileName, Err: err}
+	}
+	// "$schema" is used by editors to find the JSON Schema of the file.
+	delete(rawDoc, "$schema")
+
+	doc := map[string]jsonTable{}
+	for tableID, rawTable := range rawDoc {
+		var table jsonTable
+		if err := convertJSONValue(rawTable, &table); err != nil {
+			return &ParseError{FileName: optns.fileName, Err: fmt.Errorf("table '%s': %w", tableID, err)}
+		}
+		doc[tableID] = table
+	}
+
+	tableIDs := slices.Sorted(maps.Keys(doc))
+	for _, tableID := range tableIDs {
+		var tableName string
+		if doc[tableID].Config != nil {
+			tableName = doc[tableID].Config.TableName
+		}
+		tables.register(tableID, tableName)
+	}
+
+	type loadTable struct {
+		tableID debefix.TableID
+		config  loadTableConfig
+		rows    []debefix.MapValues
+		origins []string
+	}
+
+	var load []loadTable
+	for _, tableID := range tableIDs {
+		table := doc[tableID]
+		lt := loadTable{
+			tableID: tables.tableID(tableID),
+		}
+		if table.Config != nil {
+			lt.config = newLoadTableConfig(tables, *table.Config)
+		}
+		for rowIdx, row := range table.Rows {
+			values := debefix.MapValues{}
+			for fieldName, fieldValue := range row {
+				value, err := parseJSONValue(tables, fieldValue)
+				if err != nil {
+					return &ParseError{FileName: optns.fileName,
+						Err: fmt.Errorf("table '%s' row %d field '%s': %w", tableID, rowIdx, fieldName, err)}
+				}
+				values[fieldName] = value
+			}
+			lt.rows = append(lt.rows, values)
+			lt.origins = append(lt.origins, strings.TrimPrefix(fmt.Sprintf("%s table '%s' row %d",
+				optns.fileName, tableID, rowIdx), " "))
+		}
+		load = append(load, lt)
+	}
+
+	for _, lt := range load {
+		lt.config.apply(data, lt.tableID, len(lt.rows) > 0)
+		for rowIdx, row := range lt.rows {
+			data.Add(lt.tableID, row, debefix.WithDataAddTags(optns.tags...),
+				debefix.WithDataAddOrigin(lt.origins[rowIdx]))
+		}
+	}
+
+	return nil
+}
+
+// WriteJSON writes data as a JSON fixture, in the format read by [LoadJSON].
+// Tables are written sorted by table id, with their config (table name, dependencies, key fields and tags). Updates
+// are not written.
+// Values which have no JSON representation returns an error.
+func WriteJSON(w io.Writer, data *debefix.Data) error {
+	doc := map[string]jsonTable{}
+	for tableID, table := range data.Tables {
+		jt := jsonTable{
+			Config: newTableConfig(tableID, table),
+			Rows:   []map[string]any{},
+		}
+		for _, row := range table.Rows {
+			jrow := map[string]any{}
+			if row.RefID != "" {
+				jrow["_refid"] = map[string]any{"$refid": row.RefID}
+			}
+			for fieldName, fieldValue := range row.Values.All {
+				value, err := encodeJSONValue(fieldValue)
+				if err != nil {
+					return fmt.Errorf("table '%s' field '%s': %w", tableID, fieldName, err)
+				}
+				jrow[fieldName] = value
+			}
+			jt.Rows = append(jt.Rows, jrow)
+		}
+		doc[tableID] = jt
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+type jsonTable struct {
+	Config *tableConfig     `json:"config,omitempty"`
+	Rows   []map[string]any `json:"rows"`
+}
+
+type jsonRef struct {
+	Table string `json:"table"`
+	RefID string `json:"refid"`
+	Field string `json:"field"`
+}
+
+type jsonResolve struct {
+	Type string `json:"type,omitempty"`
+}
+
+type jsonBaseTime struct {
+	Years     int    `json:"years,omitempty"`
+	Months    int    `json:"months,omitempty"`
+	Days      int    `json:"days,omitempty"`
+	Hours     int    `json:"hours,omitempty"`
+	Minutes   int    `json:"minutes,omitempty"`
+	Seconds   int    `json:"seconds,omitempty"`
+	Truncate  string `json:"truncate,omitempty"`
+	TimeOfDay string `json:"time_of_day,omitempty"`
+	Timezone  string `json:"timezone,omitempty"`
+}
+
+// value returns the ValueBaseTimeAdd for the JSON base time.
+func (bt jsonBaseTime) value() (debefix.ValueBaseTimeAddData, error) {
+	ret := debefix.ValueBaseTimeAddData{
+		AddYears:   bt.Years,
+		AddMonths:  bt.Months,
+		AddDays:    bt.Days,
+		AddHours:   bt.Hours,
+		AddMinutes: bt.Minutes,
+		AddSeconds: bt.Seconds,
+	}
+	var err error
+	ret.Truncate, err = parseTimeTruncate(bt.Truncate)
+	if err != nil {
+		return ret, err
+	}
+	if bt.TimeOfDay != "" {
+		ret.TimeOfDay, err = parseTimeOfDay(bt.TimeOfDay)
+		if err != nil {
+			return ret, err
+		}
+	}
+	if bt.Timezone != "" {
+		ret.Location, err = parseTimezone(bt.Timezone)
+		if err != nil {
+			return ret, err
+		}
+	}
+	return ret, nil
+}
+
+// encodeJSONBaseTime returns the JSON base time for a ValueBaseTimeAdd.
+func encodeJSONBaseTime(v debefix.ValueBaseTimeAddData) (jsonBaseTime, error) {
+	ret := jsonBaseTime{
+		Years:   v.AddYears,
+		Months:  v.AddMonths,
+		Days:    v.AddDays,
+		Hours:   v.AddHours,
+		Minutes: v.AddMinutes,
+		Seconds: v.AddSeconds,
+	}
+	switch v.Truncate {
+	case debefix.TimeTruncateNone:
+	case debefix.TimeTruncateDay:
+		ret.Truncate = "day"
+	case debefix.TimeTruncateHour:
+		ret.Truncate = "hour"
+	default:
+		return ret, fmt.Errorf("unknown time truncation: %d", v.Truncate)
+	}
+	if v.TimeOfDay != nil {
+		if *v.TimeOfDay < 0 || *v.TimeOfDay >= 24*time.Hour || *v.TimeOfDay%time.Second != 0 {
+			return ret, fmt.Errorf("time of day %s cannot be encoded as JSON", *v.TimeOfDay)
+		}
+		ret.TimeOfDay = time.Time{}.Add(*v.TimeOfDay).Format(time.TimeOnly)
+	}
+	if v.Location != nil {
+		ret.Timezone = v.Location.String()
+	}
+	return ret, nil
+}
+
+type jsonFormat struct {
+	Format string `json:"format"`
+	Args   []any  `json:"args,omitempty"`
+}
+
+// parseJSONValue parses a decoded JSON value, converting the "$" objects to their debefix values.
+func parseJSONValue(tables *tableIDResolver, value any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) != 1 {
+			return parseJSONPlainValue(v), nil
+		}
+		var tag string
+		for tag = range v {
+			break
+		}
+		if !strings.HasPrefix(tag, "$") {
+			return parseJSONPlainValue(v), nil
+		}
+
+		tagValue := v[tag]
+		switch tag {
+		case "$refid":
+			var refID string
+			if err := convertJSONValue(tagValue, &refID); err != nil {
+				return nil, fmt.Errorf("invalid '%s': %w", tag, err)
+			}
+			value, err := parseRefID(refID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid '%s': %w", tag, err)
+			}
+			return value, nil
+		case "$ref":
+			var ref jsonRef
+			if err := convertJSONValue(tagValue, &ref); err != nil {
+				return nil, fmt.Errorf("invalid '%s': %w", tag, err)
+			}
+			if ref.Table == "" || ref.RefID == "" || ref.Field == "" {
+				return nil, fmt.Errorf("invalid '%s': 'table', 'refid' and 'field' are required", tag)
+			}
+			return debefix.ValueRefID(tables.tableID(ref.Table), debefix.RefID(ref.RefID), ref.Field), nil
+		case "$resolve":
+			var resolve jsonResolve
+			if err := convertJSONValue(tagValue, &resolve); err != nil {
+				return nil, fmt.Errorf("invalid '%s': %w", tag, err)
+			}
+			return parseResolve(resolve.Type)
+		case "$basetime":
+			var bt jsonBaseTime
+			if err := convertJSONValue(tagValue, &bt); err != nil {
+				return nil, fmt.Errorf("invalid '%s': %w", tag, err)
+			}
+			value, err := bt.value()
+			if err != nil {
+				return nil, fmt.Errorf("invalid '%s': %w", tag, err)
+			}
+			return value, nil
+		case "$uuid":
+			var value string
+			if err := convertJSONValue(tagValue, &value); err != nil {
+				return nil, fmt.Errorf("invalid '%s': %w", tag, err)
+			}
+			return parseUUID(value)
+		case "$format":
+			var format jsonFormat
+			if err := convertJSONValue(tagValue, &format); err != nil {
+				return nil, fmt.Errorf("invalid '%s': %w", tag, err)
+			}
+			var args []any
+			for argIdx, arg := range format.Args {
+				argValue, err := parseJSONValue(tables, arg)
+				if err != nil {
+					return nil, fmt.Errorf("invalid '%s' argument %d: %w", tag, argIdx, err)
+				}
+				args = append(args, argValue)
+			}
+			return debefix.ValueFormat(format.Format, args...), nil
+		case "$field":
+			var fieldName string
+			if err := convertJSONValue(tagValue, &fieldName); err != nil {
+				return nil, fmt.Errorf("invalid '%s': %w", tag, err)
+			}
+			if fieldName == "" {
+				return nil, fmt.Errorf("invalid '%s': field name can't be blank", tag)
+			}
+			return debefix.ValueFieldValue(fieldName), nil
+		default:
+			return nil, fmt.Errorf("unknown value type '%s'", tag)
+		}
+	default:
+		return parseJSONPlainValue(value), nil
+	}
+}
+
+// parseJSONPlainValue converts json.Number values to int or float64.
+func parseJSONPlainValue(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i)
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		ret := make(map[string]any, len(v))
+		for key, item := range v {
+			ret[key] = parseJSONPlainValue(item)
+		}
+		return ret
+	case []any:
+		ret := make([]any, len(v))
+		for idx, item := range v {
+			ret[idx] = parseJSONPlainValue(item)
+		}
+		return ret
+	default:
+		return value
+	}
+}
+
+// convertJSONValue converts a decoded JSON value to a typed value.
+func convertJSONValue(value any, target any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	return dec.Decode(target)
+}
+
+// encodeJSONValue encodes a field value to its JSON representation.
+func encodeJSONValue(value any) (any, error) {
+	switch v := value.(type) {
+	case debefix.SetValueRefIDData:
+		return map[string]any{"$refid": v.RefID}, nil
+	case debefix.ValueRefIDData:
+		return map[string]any{"$ref": jsonRef{Table: v.TableID.TableID(), RefID: string(v.RefID), Field: v.FieldName}}, nil
+	case debefix.ResolveValueResolveData:
+		return map[string]any{"$resolve": jsonResolve{}}, nil
+	case debefix.ResolveValueUUIDData:
+		return map[string]any{"$resolve": jsonResolve{Type: "uuid"}}, nil
+	case debefix.ValueBaseTimeAddData:
+		bt, err := encodeJSONBaseTime(v)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"$basetime": bt}, nil
+	case debefix.ValueUUIDData:
+		return map[string]any{"$uuid": v.Value.String()}, nil
+	case debefix.ValueGenUUIDData:
+		return map[string]any{"$uuid": ""}, nil
+	case debefix.ValueFieldValueData:
+		return map[string]any{"$field": v.FieldName}, nil
+	case debefix.ValueFormatData:
+		format := jsonFormat{Format: v.Format}
+		for argIdx, arg := range v.Args {
+			argValue, err := encodeJSONValue(arg)
+			if err != nil {
+				return nil, fmt.Errorf("argument %d: %w", argIdx, err)
+			}
+			format.Args = append(format.Args, argValue)
+		}
+		return map[string]any{"$format": format}, nil
+	case debefix.Value, debefix.ValueMultiple, debefix.ResolveValue, debefix.IsNotAValue:
+		return nil, fmt.Errorf("value of type '%T' cannot be encoded as JSON", value)
+	default:
+		return value, nil
+	}
+}
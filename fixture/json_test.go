@@ -0,0 +1,355 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rrgmc/debefix/v2"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestLoadJSON(t *testing.T) {
+	data := debefix.NewData()
+
+	err := LoadJSON(data, strings.NewReader(`{
+  "tags": {
+    "rows": [
+      {
+        "tag_id": {"$resolve": {}},
+        "_refid": {"$refid": "go"},
+        "name": "Go",
+        "created_at": {"$basetime": {"days": 1, "hours": 2}},
+        "extra": {"count": 2, "items": [1.5]}
+      }
+    ]
+  },
+  "posts": {
+    "config": {"depends": ["users"]},
+    "rows": [
+      {
+        "post_id": {"$uuid": "c06a1f3e-3578-4b56-bf51-9fb949ae5dbf"},
+        "tag_id": {"$ref": {"table": "tags", "refid": "go", "field": "tag_id"}},
+        "title": {"$format": {"format": "Post about %s", "args": [{"$ref": {"table": "tags", "refid": "go", "field": "name"}}]}},
+        "summary": {"$field": "title"}
+      }
+    ]
+  }
+}`))
+	assert.NilError(t, err)
+
+	tagsTable := data.Tables["tags"]
+	assert.Assert(t, is.Len(tagsTable.Rows, 1))
+	assert.Equal(t, debefix.RefID("go"), tagsTable.Rows[0].RefID)
+
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{
+			"tag_id":     debefix.ResolveValueResolve(),
+			"name":       "Go",
+			"created_at": debefix.ValueBaseTimeAdd(debefix.WithAddDate(1, 2, 0, 0)),
+			"extra":      map[string]any{"count": 2, "items": []any{1.5}},
+		},
+	}, tagsTable.Rows)
+
+	postsTable := data.Tables["posts"]
+	assert.DeepEqual(t, []string{"users", "tags"}, tableIDs(postsTable.Depends))
+
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{
+			"post_id": debefix.ValueUUID(uuid.MustParse("c06a1f3e-3578-4b56-bf51-9fb949ae5dbf")),
+			"tag_id":  debefix.ValueRefID(tagsTable.TableID, "go", "tag_id"),
+			"title":   debefix.ValueFormat("Post about %s", debefix.ValueRefID(tagsTable.TableID, "go", "name")),
+			"summary": debefix.ValueFieldValue("title"),
+		},
+	}, postsTable.Rows)
+}
+
+func TestLoadJSONUnknownValue(t *testing.T) {
+	err := LoadJSON(debefix.NewData(), strings.NewReader(`{"tags": {"rows": [{"tag_id": {"$unknown": 1}}]}}`),
+		WithLoadFileName("tags.json"))
+	assert.ErrorContains(t, err, "tags.json: table 'tags' row 0 field 'tag_id': unknown value type '$unknown'")
+}
+
+func TestWriteJSON(t *testing.T) {
+	data := debefix.NewData()
+	err := LoadYAML(data, strings.NewReader(`
+tags:
+  config:
+    table_name: public.tags
+  rows:
+    - tag_id: !resolve uuid
+      _refid: !refid go
+      name: Go
+      created_at: !basetime -1h
+posts:
+  rows:
+    - post_id: !uuid c06a1f3e-3578-4b56-bf51-9fb949ae5dbf
+      tag_id: !ref tags:go:tag_id
+      title: !format ["Post about %s", !ref tags:go:name]
+`))
+	assert.NilError(t, err)
+
+	var buf bytes.Buffer
+	err = WriteJSON(&buf, data)
+	assert.NilError(t, err)
+
+	loaded := debefix.NewData()
+	err = LoadJSON(loaded, &buf)
+	assert.NilError(t, err)
+
+	assert.Equal(t, "public.tags", loaded.Tables["tags"].TableID.TableName())
+	assert.Equal(t, debefix.RefID("go"), loaded.Tables["tags"].Rows[0].RefID)
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{
+			"tag_id":     debefix.ResolveValueUUID(),
+			"name":       "Go",
+			"created_at": debefix.ValueBaseTimeAdd(debefix.WithAddHours(-1)),
+		},
+	}, loaded.Tables["tags"].Rows)
+
+	postsRow := loaded.Tables["posts"].Rows[0]
+	assert.Equal(t, debefix.ValueUUID(uuid.MustParse("c06a1f3e-3578-4b56-bf51-9fb949ae5dbf")), postsRow.Values.GetOrNil("post_id"))
+	assert.Equal(t, "tags", postsRow.Values.GetOrNil("tag_id").(debefix.ValueRefIDData).TableID.TableID())
+	assert.Equal(t, "Post about %s", postsRow.Values.GetOrNil("title").(debefix.ValueFormatData).Format)
+}
+
+func TestWriteJSONUnsupportedValue(t *testing.T) {
+	data := debefix.NewData()
+	data.Add(debefix.TableName("tags"), debefix.MapValues{
+		"tag_id": debefix.ValueStatic(1),
+	})
+
+	err := WriteJSON(&bytes.Buffer{}, data)
+	assert.ErrorContains(t, err, "cannot be encoded as JSON")
+}
+
+func TestJSONSchema(t *testing.T) {
+	var schema map[string]any
+	err := json.Unmarshal(JSONSchema, &schema)
+	assert.NilError(t, err)
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+
+	fileNames, err := filepath.Glob("testdata/*.json")
+	assert.NilError(t, err)
+	assert.Assert(t, len(fileNames) > 0)
+	for _, fileName := range fileNames {
+		t.Run(filepath.Base(fileName), func(t *testing.T) {
+			file, err := os.ReadFile(fileName)
+			assert.NilError(t, err)
+			assert.NilError(t, validateJSONSchema(t, schema, file))
+			assert.NilError(t, LoadJSON(debefix.NewData(), bytes.NewReader(file)))
+		})
+	}
+
+	for _, invalid := range []string{
+		`{"tags": {"rows": [{"_refid": {"$refid": ""}}]}}`,
+		`{"tags": {"rows": [{"_refid": {"$refid": "  "}}]}}`,
+		`{"tags": {"rows": [{"name": {"$field": ""}}]}}`,
+		`{"tags": {"rows": [{"tag_id": {"$unknown": 1}}]}}`,
+		`{"tags": {"config": {"unknown": 1}}}`,
+	} {
+		assert.Assert(t, validateJSONSchema(t, schema, []byte(invalid)) != nil, invalid)
+		assert.Assert(t, LoadJSON(debefix.NewData(), strings.NewReader(invalid)) != nil, invalid)
+	}
+}
+
+func TestJSONTableConfig(t *testing.T) {
+	data := debefix.NewData()
+	data.AddBreakableDependencies(debefix.TableName("posts"), debefix.TableName("users"))
+	data.SetTableKeyFields(debefix.TableName("posts"), "post_id")
+	data.SetTableTags(debefix.TableName("posts"), "blog")
+	data.Add(debefix.TableName("posts"), debefix.MapValues{"post_id": 1})
+
+	var buf bytes.Buffer
+	err := WriteJSON(&buf, data)
+	assert.NilError(t, err)
+
+	loaded := debefix.NewData()
+	err = LoadJSON(loaded, &buf)
+	assert.NilError(t, err)
+
+	posts := loaded.Tables["posts"]
+	assert.DeepEqual(t, []debefix.TableID{debefix.TableName("users")}, posts.BreakableDepends)
+	assert.DeepEqual(t, []string{"post_id"}, posts.KeyFields)
+	assert.DeepEqual(t, []string{"blog"}, posts.Tags)
+
+	loaded = debefix.NewData()
+	err = LoadYAML(loaded, strings.NewReader(`
+posts:
+  config:
+    breakable_depends: [users]
+    key_fields: [post_id]
+    tags: [blog]
+  rows:
+    - post_id: 1
+`))
+	assert.NilError(t, err)
+	posts = loaded.Tables["posts"]
+	assert.DeepEqual(t, []debefix.TableID{debefix.TableName("users")}, posts.BreakableDepends)
+	assert.DeepEqual(t, []string{"post_id"}, posts.KeyFields)
+	assert.DeepEqual(t, []string{"blog"}, posts.Tags)
+}
+
+func TestJSONBaseTime(t *testing.T) {
+	data := debefix.NewData()
+	err := LoadJSON(data, strings.NewReader(`{"events": {"rows": [{"starts_at": {"$basetime": {"months": -1, "days": -2,
+		"truncate": "day", "time_of_day": "09:30:00", "timezone": "America/Sao_Paulo"}}}]}}`))
+	assert.NilError(t, err)
+
+	assertValue := func(data *debefix.Data) {
+		value, ok := data.Tables["events"].Rows[0].Values.GetOrNil("starts_at").(debefix.ValueBaseTimeAddData)
+		assert.Assert(t, ok)
+		assert.Equal(t, -1, value.AddMonths)
+		assert.Equal(t, -2, value.AddDays)
+		assert.Equal(t, debefix.TimeTruncateDay, value.Truncate)
+		assert.Equal(t, 9*time.Hour+30*time.Minute, *value.TimeOfDay)
+		assert.Equal(t, "America/Sao_Paulo", value.Location.String())
+	}
+	assertValue(data)
+
+	var buf bytes.Buffer
+	err = WriteJSON(&buf, data)
+	assert.NilError(t, err)
+
+	loaded := debefix.NewData()
+	err = LoadJSON(loaded, &buf)
+	assert.NilError(t, err)
+	assertValue(loaded)
+
+	err = LoadJSON(debefix.NewData(), strings.NewReader(`{"events": {"rows": [{"starts_at": {"$basetime": {"truncate": "week"}}}]}}`))
+	assert.ErrorContains(t, err, "unknown truncate 'week'")
+}
+
+// validateJSONSchema validates a JSON document with the subset of JSON Schema keywords used by [JSONSchema].
+func validateJSONSchema(t *testing.T, schema map[string]any, doc []byte) error {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return err
+	}
+	defs := schema["$defs"].(map[string]any)
+
+	var validate func(schema any, value any, path string) error
+	validate = func(s any, value any, path string) error {
+		schema, ok := s.(map[string]any)
+		if !ok {
+			if s == false {
+				return fmt.Errorf("%s: not allowed", path)
+			}
+			return nil
+		}
+		if ref, ok := schema["$ref"].(string); ok {
+			if err := validate(defs[strings.TrimPrefix(ref, "#/$defs/")], value, path); err != nil {
+				return err
+			}
+		}
+		if types, ok := schema["type"]; ok {
+			if !slices.ContainsFunc(toSlice(types), func(typ any) bool { return jsonSchemaType(value, typ.(string)) }) {
+				return fmt.Errorf("%s: expected type %v", path, types)
+			}
+		}
+		if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+			return fmt.Errorf("%s: value not in %v", path, enum)
+		}
+		if str, ok := value.(string); ok {
+			if minLength, ok := schema["minLength"].(float64); ok && len(str) < int(minLength) {
+				return fmt.Errorf("%s: shorter than %v", path, minLength)
+			}
+			if maxLength, ok := schema["maxLength"].(float64); ok && len(str) > int(maxLength) {
+				return fmt.Errorf("%s: longer than %v", path, maxLength)
+			}
+			if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(str) {
+				return fmt.Errorf("%s: doesn't match %s", path, pattern)
+			}
+		}
+		if obj, ok := value.(map[string]any); ok {
+			if minProperties, ok := schema["minProperties"].(float64); ok && len(obj) < int(minProperties) {
+				return fmt.Errorf("%s: less than %v properties", path, minProperties)
+			}
+			if maxProperties, ok := schema["maxProperties"].(float64); ok && len(obj) > int(maxProperties) {
+				return fmt.Errorf("%s: more than %v properties", path, maxProperties)
+			}
+			for _, required := range toSlice(schema["required"]) {
+				if _, ok := obj[required.(string)]; !ok {
+					return fmt.Errorf("%s: missing required property '%s'", path, required)
+				}
+			}
+			properties, _ := schema["properties"].(map[string]any)
+			for key, item := range obj {
+				if names, ok := schema["propertyNames"]; ok {
+					if err := validate(names, key, path+"/"+key); err != nil {
+						return err
+					}
+				}
+				propertySchema, ok := properties[key]
+				if !ok {
+					if propertySchema, ok = schema["additionalProperties"]; !ok {
+						continue
+					}
+				}
+				if err := validate(propertySchema, item, path+"/"+key); err != nil {
+					return err
+				}
+			}
+		}
+		if arr, ok := value.([]any); ok && schema["items"] != nil {
+			for idx, item := range arr {
+				if err := validate(schema["items"], item, fmt.Sprintf("%s/%d", path, idx)); err != nil {
+					return err
+				}
+			}
+		}
+		if anyOf, ok := schema["anyOf"].([]any); ok {
+			if !slices.ContainsFunc(anyOf, func(item any) bool { return validate(item, value, path) == nil }) {
+				return fmt.Errorf("%s: doesn't match any of the schemas", path)
+			}
+		}
+		if not, ok := schema["not"]; ok && validate(not, value, path) == nil {
+			return fmt.Errorf("%s: matches a disallowed schema", path)
+		}
+		return nil
+	}
+	return validate(schema, value, "")
+}
+
+// jsonSchemaType returns whether a JSON value decoded using json.Number has the JSON Schema type.
+func jsonSchemaType(value any, typ string) bool {
+	switch v := value.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case json.Number:
+		_, err := v.Int64()
+		return typ == "number" || (typ == "integer" && err == nil)
+	case string:
+		return typ == "string"
+	case []any:
+		return typ == "array"
+	case map[string]any:
+		return typ == "object"
+	default:
+		return false
+	}
+}
+
+func toSlice(value any) []any {
+	if s, ok := value.([]any); ok {
+		return s
+	}
+	if value == nil {
+		return nil
+	}
+	return []any{value}
+}
@@ -0,0 +1,216 @@
+package fixture
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rrgmc/debefix/v2"
+)
+
+// parseRefID parses a RefID, which can't be blank.
+func parseRefID(value string) (debefix.SetValueRefIDData, error) {
+	if strings.TrimSpace(value) == "" {
+		return debefix.SetValueRefIDData{}, errors.New("RefID can't be blank")
+	}
+	return debefix.SetValueRefID(debefix.RefID(value)), nil
+}
+
+// parseRef parses a reference in the "table:refid:field" format.
+func parseRef(ref string) (tableID string, refID debefix.RefID, fieldName string, err error) {
+	first := strings.Index(ref, ":")
+	last := strings.LastIndex(ref, ":")
+	if first < 0 || first == last {
+		return "", "", "", fmt.Errorf("invalid reference '%s', expected 'table:refid:field'", ref)
+	}
+	tableID, refID, fieldName = ref[:first], debefix.RefID(ref[first+1:last]), ref[last+1:]
+	if tableID == "" || refID == "" || fieldName == "" {
+		return "", "", "", fmt.Errorf("invalid reference '%s', expected 'table:refid:field'", ref)
+	}
+	return tableID, refID, fieldName, nil
+}
+
+// parseBaseTimeAdd parses a base time offset like "+1h", "-2d" or "1y2mo3d4h30m15s", optionally followed by
+// space-separated options: "truncate=day" (or "hour"), "time=09:30:00" (time of day) and "tz=America/Sao_Paulo".
+func parseBaseTimeAdd(offset string) (debefix.ValueBaseTimeAddData, error) {
+	var ret debefix.ValueBaseTimeAddData
+
+	fields := strings.Fields(offset)
+	if len(fields) > 0 && !strings.Contains(fields[0], "=") {
+		err := parseBaseTimeOffset(&ret, fields[0])
+		if err != nil {
+			return ret, fmt.Errorf("invalid base time offset '%s': %w", offset, err)
+		}
+		fields = fields[1:]
+	}
+
+	for _, field := range fields {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return ret, fmt.Errorf("invalid base time option '%s'", field)
+		}
+		var err error
+		switch name {
+		case "truncate":
+			ret.Truncate, err = parseTimeTruncate(value)
+		case "time":
+			ret.TimeOfDay, err = parseTimeOfDay(value)
+		case "tz":
+			ret.Location, err = parseTimezone(value)
+		default:
+			err = fmt.Errorf("unknown base time option '%s'", name)
+		}
+		if err != nil {
+			return ret, err
+		}
+	}
+
+	return ret, nil
+}
+
+// parseBaseTimeOffset parses the offset part of a base time, like "-1y2mo3d4h30m15s".
+func parseBaseTimeOffset(ret *debefix.ValueBaseTimeAddData, s string) error {
+	sign := 1
+	switch s[0] {
+	case '+':
+		s = s[1:]
+	case '-':
+		sign = -1
+		s = s[1:]
+	}
+	if s == "" {
+		return errors.New("missing amount")
+	}
+
+	for s != "" {
+		numEnd := strings.IndexFunc(s, func(r rune) bool {
+			return r < '0' || r > '9'
+		})
+		if numEnd <= 0 {
+			return errors.New("missing amount")
+		}
+		amount, err := strconv.Atoi(s[:numEnd])
+		if err != nil {
+			return err
+		}
+		amount *= sign
+		unitEnd := numEnd + 1
+		switch {
+		case s[numEnd] == 'y':
+			ret.AddYears += amount
+		case strings.HasPrefix(s[numEnd:], "mo"):
+			ret.AddMonths += amount
+			unitEnd++
+		case s[numEnd] == 'd':
+			ret.AddDays += amount
+		case s[numEnd] == 'h':
+			ret.AddHours += amount
+		case s[numEnd] == 'm':
+			ret.AddMinutes += amount
+		case s[numEnd] == 's':
+			ret.AddSeconds += amount
+		default:
+			return fmt.Errorf("unknown unit '%c'", s[numEnd])
+		}
+		s = s[unitEnd:]
+	}
+
+	return nil
+}
+
+// parseTimeTruncate parses a time truncation, "day" or "hour".
+func parseTimeTruncate(value string) (debefix.TimeTruncate, error) {
+	switch value {
+	case "":
+		return debefix.TimeTruncateNone, nil
+	case "day":
+		return debefix.TimeTruncateDay, nil
+	case "hour":
+		return debefix.TimeTruncateHour, nil
+	default:
+		return debefix.TimeTruncateNone, fmt.Errorf("unknown truncate '%s'", value)
+	}
+}
+
+// parseTimeOfDay parses a time of day in the "15:04:05" format, returning the duration since midnight.
+func parseTimeOfDay(value string) (*time.Duration, error) {
+	tod, err := time.Parse(time.TimeOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time of day '%s': %w", value, err)
+	}
+	timeOfDay := tod.Sub(time.Date(tod.Year(), tod.Month(), tod.Day(), 0, 0, 0, 0, tod.Location()))
+	return &timeOfDay, nil
+}
+
+// parseTimezone loads a timezone location by name, like "America/Sao_Paulo".
+func parseTimezone(value string) (*time.Location, error) {
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone '%s': %w", value, err)
+	}
+	return location, nil
+}
+
+// parseUUID returns a UUID value generated when resolving if the string is blank, so it is reproducible using
+// [debefix.WithResolveOptionUUIDSeed], or a fixed one otherwise.
+func parseUUID(value string) (debefix.Value, error) {
+	if value == "" {
+		return debefix.ValueGenUUID(), nil
+	}
+	u, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID '%s': %w", value, err)
+	}
+	return debefix.ValueUUID(u), nil
+}
+
+// parseResolve returns a ResolveValue for the passed type name. Blank means no parsing.
+func parseResolve(typeName string) (debefix.ResolveValue, error) {
+	switch typeName {
+	case "":
+		return debefix.ResolveValueResolve(), nil
+	case "uuid":
+		return debefix.ResolveValueUUID(), nil
+	default:
+		return nil, fmt.Errorf("unknown resolve type '%s'", typeName)
+	}
+}
+
+// tableIDResolver returns the TableID for fixture table ids, reusing the ones already known when available.
+type tableIDResolver struct {
+	data        *debefix.Data
+	tableIDFunc TableIDFunc
+	known       map[string]debefix.TableID
+}
+
+func newTableIDResolver(data *debefix.Data, tableIDFunc TableIDFunc) *tableIDResolver {
+	return &tableIDResolver{
+		data:        data,
+		tableIDFunc: tableIDFunc,
+		known:       map[string]debefix.TableID{},
+	}
+}
+
+// register registers a table declared in the fixture, with an optional table name.
+func (r *tableIDResolver) register(id string, tableName string) debefix.TableID {
+	if tableName == "" {
+		return r.tableID(id)
+	}
+	ret := r.tableIDFunc(id, tableName)
+	r.known[id] = ret
+	return ret
+}
+
+// tableID returns the TableID of a table id.
+func (r *tableIDResolver) tableID(id string) debefix.TableID {
+	if tableID, ok := r.known[id]; ok {
+		return tableID
+	}
+	if table, ok := r.data.Tables[id]; ok {
+		return table.TableID
+	}
+	return r.tableIDFunc(id, "")
+}
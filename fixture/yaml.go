@@ -0,0 +1,280 @@
+package fixture
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rrgmc/debefix/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadYAML loads a YAML fixture into data. Rows are added in the order they appear in the file.
+//
+// The root of the file is a map of table ids, each containing an optional "config" and a list of "rows":
+//
+//	users:
+//	  config:
+//	    table_name: public.users     # optional, the table id is used if not set
+//	    depends: [countries]         # optional, dependencies on other tables
+//	    breakable_depends: [regions] # optional, dependencies ignored if part of a circular dependency
+//	    key_fields: [user_id]        # optional, fields which uniquely identify a row
+//	    tags: [demo]                 # optional, tags of all rows of the table
+//	  rows:
+//	    - user_id: !resolve
+//	      _refid: !refid johndoe
+//	      name: John Doe
+//	      created_at: !basetime +1h
+//	posts:
+//	  rows:
+//	    - post_id: !uuid
+//	      user_id: !ref users:johndoe:user_id
+//	      title: !format ["Post by %s", !ref users:johndoe:name]
+//
+// The supported tags are:
+//
+//   - !refid <refid>: sets the row RefID ([debefix.SetValueRefID]).
+//   - !ref <table>:<refid>:<field>: a field of another row ([debefix.ValueRefID]).
+//   - !resolve [uuid]: a value resolved by the resolve callback ([debefix.ResolveValueResolve] or
+//     [debefix.ResolveValueUUID]).
+//   - !basetime [offset] [options]: the resolve base time plus an offset like "+1h", "-2d" or "1y2mo3d4h30m15s"
+//     (years, months, days, hours, minutes and seconds), optionally followed by the options "truncate=day" (or
+//     "hour"), "time=09:30:00" (time of day) and "tz=America/Sao_Paulo" ([debefix.ValueBaseTimeAdd]).
+//   - !uuid [value]: a fixed UUID, or a random one generated when resolving if blank ([debefix.ValueUUID] or
+//     [debefix.ValueGenUUID]).
+//   - !format [format, args...]: formats a string using other values ([debefix.ValueFormat]).
+//   - !field <field>: the value of another field of the same row ([debefix.ValueFieldValue]).
+//
+// The file is fully parsed before any row is added to data, so a parse error don't leave partial data.
+func LoadYAML(data *debefix.Data, r io.Reader, options ...LoadOption) error {
+	optns := parseLoadOptions(options...)
+	l := &yamlLoader{
+		optns:  optns,
+		tables: newTableIDResolver(data, optns.tableIDFunc),
+	}
+
+	var tables []yamlTable
+	dec := yaml.NewDecoder(r)
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return &ParseError{FileName: optns.fileName, Err: err}
+		}
+		docTables, err := l.parseDocument(&doc)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, docTables...)
+	}
+
+	for _, table := range tables {
+		if err := l.loadTable(data, table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadYAMLFile loads a YAML fixture file into data. See [LoadYAML] for the file format.
+func LoadYAMLFile(data *debefix.Data, fileName string, options ...LoadOption) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return LoadYAML(data, f, append([]LoadOption{WithLoadFileName(fileName)}, options...)...)
+}
+
+type yamlLoader struct {
+	optns  loadOptions
+	tables *tableIDResolver
+}
+
+type yamlTable struct {
+	tableID debefix.TableID
+	config  loadTableConfig
+	rows    *yaml.Node
+}
+
+// parseDocument parses the tables of a document, registering their table ids.
+func (l *yamlLoader) parseDocument(doc *yaml.Node) ([]yamlTable, error) {
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			return nil, nil
+		}
+		doc = doc.Content[0]
+	}
+	if doc.Kind == yaml.ScalarNode && doc.Tag == "!!null" {
+		return nil, nil
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil, l.errorf(doc, "expected a map of tables")
+	}
+
+	var ret []yamlTable
+	for i := 0; i < len(doc.Content); i += 2 {
+		keyNode, tableNode := doc.Content[i], doc.Content[i+1]
+		if tableNode.Kind != yaml.MappingNode {
+			return nil, l.errorf(tableNode, "table '%s' must be a map", keyNode.Value)
+		}
+
+		var configNode *yaml.Node
+		table := yamlTable{}
+		for j := 0; j < len(tableNode.Content); j += 2 {
+			switch tableNode.Content[j].Value {
+			case "config":
+				configNode = tableNode.Content[j+1]
+			case "rows":
+				table.rows = tableNode.Content[j+1]
+				if table.rows.Kind != yaml.SequenceNode && table.rows.Tag != "!!null" {
+					return nil, l.errorf(table.rows, "rows of table '%s' must be a list", keyNode.Value)
+				}
+			default:
+				return nil, l.errorf(tableNode.Content[j], "unknown table key '%s'", tableNode.Content[j].Value)
+			}
+		}
+
+		var config tableConfig
+		if configNode != nil {
+			if err := configNode.Decode(&config); err != nil {
+				return nil, l.errorf(configNode, "error parsing config of table '%s': %s", keyNode.Value, err)
+			}
+		}
+		table.tableID = l.tables.register(keyNode.Value, config.TableName)
+		table.config = newLoadTableConfig(l.tables, config)
+		ret = append(ret, table)
+	}
+	return ret, nil
+}
+
+// loadTable adds the table and its rows to data.
+func (l *yamlLoader) loadTable(data *debefix.Data, table yamlTable) error {
+	var rows []debefix.MapValues
+	var origins []string
+	if table.rows != nil {
+		for _, rowNode := range table.rows.Content {
+			if rowNode.Kind != yaml.MappingNode {
+				return l.errorf(rowNode, "row of table '%s' must be a map", table.tableID.TableID())
+			}
+			row := debefix.MapValues{}
+			for i := 0; i < len(rowNode.Content); i += 2 {
+				value, err := l.parseValue(rowNode.Content[i+1])
+				if err != nil {
+					return err
+				}
+				row[rowNode.Content[i].Value] = value
+			}
+			rows = append(rows, row)
+			origins = append(origins, position(l.optns.fileName, rowNode.Line, rowNode.Column))
+		}
+	}
+
+	table.config.apply(data, table.tableID, len(rows) > 0)
+	for rowIdx, row := range rows {
+		data.Add(table.tableID, row, debefix.WithDataAddTags(l.optns.tags...),
+			debefix.WithDataAddOrigin(origins[rowIdx]))
+	}
+	return nil
+}
+
+// parseValue parses a field value, converting the supported tags to their debefix values.
+func (l *yamlLoader) parseValue(node *yaml.Node) (any, error) {
+	switch node.Tag {
+	case "!refid":
+		if err := l.checkScalar(node); err != nil {
+			return nil, err
+		}
+		value, err := parseRefID(node.Value)
+		if err != nil {
+			return nil, l.errorf(node, "invalid '%s': %s", node.Tag, err)
+		}
+		return value, nil
+	case "!ref":
+		if err := l.checkScalar(node); err != nil {
+			return nil, err
+		}
+		tableID, refID, fieldName, err := parseRef(node.Value)
+		if err != nil {
+			return nil, l.errorf(node, "%s", err)
+		}
+		return debefix.ValueRefID(l.tables.tableID(tableID), refID, fieldName), nil
+	case "!resolve":
+		if err := l.checkScalar(node); err != nil {
+			return nil, err
+		}
+		value, err := parseResolve(node.Value)
+		if err != nil {
+			return nil, l.errorf(node, "%s", err)
+		}
+		return value, nil
+	case "!basetime":
+		if err := l.checkScalar(node); err != nil {
+			return nil, err
+		}
+		value, err := parseBaseTimeAdd(node.Value)
+		if err != nil {
+			return nil, l.errorf(node, "%s", err)
+		}
+		return value, nil
+	case "!uuid":
+		if err := l.checkScalar(node); err != nil {
+			return nil, err
+		}
+		value, err := parseUUID(node.Value)
+		if err != nil {
+			return nil, l.errorf(node, "%s", err)
+		}
+		return value, nil
+	case "!field":
+		if err := l.checkScalar(node); err != nil {
+			return nil, err
+		}
+		return debefix.ValueFieldValue(node.Value), nil
+	case "!format":
+		if node.Kind != yaml.SequenceNode || len(node.Content) == 0 || node.Content[0].Kind != yaml.ScalarNode {
+			return nil, l.errorf(node, "tag '%s' must be a list with the format as the first item", node.Tag)
+		}
+		var args []any
+		for _, argNode := range node.Content[1:] {
+			arg, err := l.parseValue(argNode)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		return debefix.ValueFormat(node.Content[0].Value, args...), nil
+	}
+
+	if strings.HasPrefix(node.Tag, "!") && !strings.HasPrefix(node.Tag, "!!") {
+		return nil, l.errorf(node, "unknown tag '%s'", node.Tag)
+	}
+
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return nil, l.errorf(node, "%s", err)
+	}
+	return value, nil
+}
+
+func (l *yamlLoader) checkScalar(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return l.errorf(node, "tag '%s' must be a scalar value", node.Tag)
+	}
+	return nil
+}
+
+func (l *yamlLoader) errorf(node *yaml.Node, format string, args ...any) error {
+	return &ParseError{
+		FileName: l.optns.fileName,
+		Line:     node.Line,
+		Column:   node.Column,
+		Err:      fmt.Errorf(format, args...),
+	}
+}
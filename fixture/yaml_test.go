@@ -0,0 +1,218 @@
+package fixture
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rrgmc/debefix/v2"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestLoadYAML(t *testing.T) {
+	data := debefix.NewData()
+
+	err := LoadYAML(data, strings.NewReader(`
+users:
+  config:
+    table_name: public.users
+tags:
+  rows:
+    - tag_id: !resolve
+      _refid: !refid go
+      name: Go
+      created_at: !basetime +1d2h
+posts:
+  config:
+    depends: [users]
+  rows:
+    - post_id: 1
+      tag_id: !ref tags:go:tag_id
+      title: !format ["Post about %s", !ref tags:go:name]
+      summary: !field title
+`))
+	assert.NilError(t, err)
+	assert.NilError(t, data.Err())
+
+	usersTable, ok := data.Tables["users"]
+	assert.Assert(t, ok, "users table not found")
+	assert.Equal(t, "public.users", usersTable.TableID.TableName())
+	assert.Assert(t, is.Len(usersTable.Rows, 0))
+
+	tagsTable, ok := data.Tables["tags"]
+	assert.Assert(t, ok, "tags table not found")
+	assert.Assert(t, is.Len(tagsTable.Rows, 1))
+	assert.Equal(t, debefix.RefID("go"), tagsTable.Rows[0].RefID)
+
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{
+			"tag_id":     debefix.ResolveValueResolve(),
+			"name":       "Go",
+			"created_at": debefix.ValueBaseTimeAdd(debefix.WithAddDate(1, 2, 0, 0)),
+		},
+	}, tagsTable.Rows)
+
+	postsTable, ok := data.Tables["posts"]
+	assert.Assert(t, ok, "posts table not found")
+	assert.DeepEqual(t, []string{"users", "tags"}, tableIDs(postsTable.Depends))
+
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{
+			"post_id": 1,
+			"tag_id":  debefix.ValueRefID(tagsTable.TableID, "go", "tag_id"),
+			"title":   debefix.ValueFormat("Post about %s", debefix.ValueRefID(tagsTable.TableID, "go", "name")),
+			"summary": debefix.ValueFieldValue("title"),
+		},
+	}, postsTable.Rows)
+}
+
+func TestLoadYAMLResolve(t *testing.T) {
+	ctx := context.Background()
+	data := debefix.NewData()
+
+	err := LoadYAML(data, strings.NewReader(`
+posts:
+  rows:
+    - post_id: 1
+      tag_id: !ref tags:go:tag_id
+tags:
+  rows:
+    - tag_id: !resolve
+      _refid: !refid go
+`))
+	assert.NilError(t, err)
+
+	resolvedData, err := debefix.Resolve(ctx, data,
+		func(ctx context.Context, resolveInfo debefix.ResolveInfo, values debefix.ValuesMutable) error {
+			if resolveInfo.TableID.TableID() == "tags" {
+				values.Set("tag_id", 15)
+			}
+			return nil
+		})
+	assert.NilError(t, err)
+
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{
+			"post_id": 1,
+			"tag_id":  15,
+		},
+	}, resolvedData.Tables["posts"].Rows)
+}
+
+func TestLoadYAMLError(t *testing.T) {
+	data := debefix.NewData()
+
+	err := LoadYAML(data, strings.NewReader(`
+tags:
+  rows:
+    - tag_id: 1
+    - tag_id: !unknown 2
+`), WithLoadFileName("tags.yaml"))
+	var perr *ParseError
+	assert.Assert(t, errors.As(err, &perr))
+	assert.Equal(t, "tags.yaml", perr.FileName)
+	assert.Equal(t, 5, perr.Line)
+	assert.Assert(t, is.Len(data.Tables, 0))
+
+	for _, refID := range []string{`""`, `"  "`, ``} {
+		err = LoadYAML(debefix.NewData(), strings.NewReader("tags:\n  rows:\n    - _refid: !refid "+refID+"\n"))
+		assert.ErrorContains(t, err, "invalid '!refid': RefID can't be blank", refID)
+	}
+}
+
+func TestLoadYAMLTags(t *testing.T) {
+	data := debefix.NewData()
+
+	err := LoadYAML(data, strings.NewReader(`
+tags:
+  rows:
+    - tag_id: 1
+`), WithLoadTags("dev", "demo"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"dev", "demo"}, data.Tables["tags"].Rows[0].Tags)
+}
+
+func tableIDs(tableIDs []debefix.TableID) []string {
+	var ret []string
+	for _, tableID := range tableIDs {
+		ret = append(ret, tableID.TableID())
+	}
+	return ret
+}
+
+func TestLoadYAMLDuplicateRefID(t *testing.T) {
+	data := debefix.NewData()
+
+	err := LoadYAML(data, strings.NewReader(`
+tags:
+  rows:
+    - tag_id: 1
+      _refid: !refid "go"
+    - tag_id: 2
+      _refid: !refid "go"
+`), WithLoadFileName("tags.yaml"))
+	assert.NilError(t, err)
+	assert.Assert(t, errors.Is(data.Err(), debefix.ErrDuplicateRefID))
+	assert.ErrorContains(t, data.Err(), "row 1 (tags.yaml:6:7) was already defined by row 0 (tags.yaml:4:7)")
+}
+
+func TestLoadYAMLBaseTime(t *testing.T) {
+	data := debefix.NewData()
+
+	err := LoadYAML(data, strings.NewReader(`
+events:
+  rows:
+    - starts_at: !basetime -1y2mo3d4h5m6s truncate=day time=09:30:00 tz=America/Sao_Paulo
+    - starts_at: !basetime time=18:00:00
+`))
+	assert.NilError(t, err)
+
+	value, ok := data.Tables["events"].Rows[0].Values.GetOrNil("starts_at").(debefix.ValueBaseTimeAddData)
+	assert.Assert(t, ok)
+	assert.Equal(t, -1, value.AddYears)
+	assert.Equal(t, -2, value.AddMonths)
+	assert.Equal(t, -3, value.AddDays)
+	assert.Equal(t, -4, value.AddHours)
+	assert.Equal(t, -5, value.AddMinutes)
+	assert.Equal(t, -6, value.AddSeconds)
+	assert.Equal(t, debefix.TimeTruncateDay, value.Truncate)
+	assert.Equal(t, 9*time.Hour+30*time.Minute, *value.TimeOfDay)
+	assert.Equal(t, "America/Sao_Paulo", value.Location.String())
+
+	value, ok = data.Tables["events"].Rows[1].Values.GetOrNil("starts_at").(debefix.ValueBaseTimeAddData)
+	assert.Assert(t, ok)
+	assert.Equal(t, 18*time.Hour, *value.TimeOfDay)
+
+	for _, invalid := range []string{"1w", "1d truncate=week", "time=25:00:00", "tz=Invalid/Zone", "1d round=day"} {
+		err = LoadYAML(debefix.NewData(), strings.NewReader("events:\n  rows:\n    - starts_at: !basetime "+invalid+"\n"))
+		assert.Assert(t, err != nil, invalid)
+	}
+}
+
+func TestLoadYAMLUUID(t *testing.T) {
+	ctx := context.Background()
+
+	resolvePostID := func() any {
+		data := debefix.NewData()
+		err := LoadYAML(data, strings.NewReader(`
+posts:
+  rows:
+    - post_id: !uuid
+`))
+		assert.NilError(t, err)
+		resolvedData, err := debefix.Resolve(ctx, data, debefix.ResolveCheckCallback,
+			debefix.WithResolveOptionUUIDSeed(1))
+		assert.NilError(t, err)
+		return resolvedData.Tables["posts"].Rows[0].Values.GetOrNil("post_id")
+	}
+
+	// blank UUIDs are generated when resolving, so they are reproducible using a UUID seed.
+	postID := resolvePostID()
+	_, ok := postID.(uuid.UUID)
+	assert.Assert(t, ok)
+	assert.Equal(t, postID, resolvePostID())
+}
@@ -0,0 +1,167 @@
+package debefix
+
+import (
+	"context"
+	"maps"
+
+	"github.com/google/uuid"
+)
+
+// PlanOperation is one operation that Resolve would execute, in the order it would be executed.
+type PlanOperation struct {
+	Type            ResolveType    // type of the operation (add or update).
+	TableID         TableID        // table of the row.
+	UpdateKeyFields []string       // if type is update, the names of the key fields to be used to find the row.
+	InternalID      uuid.UUID      // internal id of the row.
+	RefID           RefID          // RefID of the row, if set.
+	Values          map[string]any // field values which are known before resolving.
+	ResolveFields   []string       // sorted fields with ResolveValue values, to be resolved by the resolve callback.
+	ValueFields     []string       // sorted fields with Value or ValueMultiple values, to be resolved at resolve time.
+	Batch           bool           // whether the row is added with the other batch rows of its table in a single call.
+}
+
+// Plan returns the list of operations that Resolve would execute for "data", in the same order, without calling any
+// callback or resolving any value.
+// Rows with circular dependencies are listed without the fields which would be set later by updates, like in Resolve.
+// Updates are applied to copies of the rows, so the operations of later updates of the same row include the changes
+// of the previous ones. If WithResolveOptionBatchCallback is set, the rows which would be resolved in a batch are
+// listed first for each table, with Batch set.
+// Only ResolveOption values that change which operations are executed are used, processes and callbacks are ignored.
+func Plan(ctx context.Context, data *Data, options ...ResolveOption) ([]PlanOperation, error) {
+	var optns resolveOptions
+	for _, opt := range options {
+		opt(&optns)
+	}
+
+	if optns.validate {
+		err := validateData(data, optns.base)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, layers, err := prepareResolve(data, optns)
+	if err != nil {
+		return nil, err
+	}
+
+	// updates query the data rows, so they can be found by RefID and internal id before being resolved. The rows are
+	// copies, so the updates can be applied to them like in Resolve without changing data.
+	planData := &ResolvedData{Data: Data{Tables: map[string]*Table{}}}
+	if optns.base != nil {
+		planData = optns.base.clone()
+	}
+	planRows := map[*Row]*Row{}
+	for tableID, table := range data.Tables {
+		planTable, ok := planData.Tables[tableID]
+		if !ok {
+			planTable = table.emptyCopy()
+			planData.Tables[tableID] = planTable
+		}
+		for _, row := range table.Rows {
+			planRow := *row
+			planRow.Values = MapValues(maps.Collect(row.Values.All))
+			planTable.Rows = append(planTable.Rows, &planRow)
+			planRows[row] = &planRow
+		}
+	}
+
+	var ret []PlanOperation
+	for _, layer := range layers {
+		for _, table := range layer {
+			// rows resolved by the batch callback are added before the other rows of the table.
+			batchRows := map[*Row]bool{}
+			if optns.batchFunc != nil {
+				for _, row := range table.Rows {
+					if !isBatchRow(table.TableID, row) {
+						continue
+					}
+					op, err := planRowOperation(ResolveTypeAdd, table.TableID, nil, planRows[row])
+					if err != nil {
+						return nil, err
+					}
+					op.Batch = true
+					ret = append(ret, op)
+					batchRows[row] = true
+				}
+			}
+
+			for _, row := range table.Rows {
+				if !batchRows[row] {
+					op, err := planRowOperation(ResolveTypeAdd, table.TableID, nil, planRows[row])
+					if err != nil {
+						return nil, err
+					}
+					ret = append(ret, op)
+				}
+
+				for _, update := range row.Updates {
+					ops, err := planUpdate(ctx, planData, update)
+					if err != nil {
+						return nil, err
+					}
+					ret = append(ret, ops...)
+				}
+			}
+		}
+	}
+
+	for _, update := range data.Updates {
+		ops, err := planUpdate(ctx, planData, update)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, ops...)
+	}
+
+	return ret, nil
+}
+
+// planUpdate returns the operations of an update, applying its action on the plan rows.
+func planUpdate(ctx context.Context, planData *ResolvedData, update Update) ([]PlanOperation, error) {
+	updateData, err := update.Query.Rows(ctx, planData)
+	if err != nil {
+		return nil, NewResolveErrorf("error finding rows to update: %w", err)
+	}
+
+	var ret []PlanOperation
+	for _, ud := range updateData {
+		err := update.Action.UpdateRow(ctx, planData, ud.TableID, ud.Row)
+		if err != nil {
+			return nil, NewResolveErrorf("error updating row: %w", err)
+		}
+		op, err := planRowOperation(ResolveTypeUpdate, ud.TableID, ud.keyFields(&planData.Data), ud.Row)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, op)
+	}
+	return ret, nil
+}
+
+// planRowOperation returns the operation for a row, splitting its fields by how they would be resolved.
+func planRowOperation(resolveType ResolveType, tableID TableID, updateKeyFields []string, row *Row) (PlanOperation, error) {
+	ret := PlanOperation{
+		Type:            resolveType,
+		TableID:         tableID,
+		UpdateKeyFields: updateKeyFields,
+		InternalID:      row.InternalID,
+		RefID:           row.RefID,
+		Values:          map[string]any{},
+	}
+	for _, fieldName := range sortedFieldNames(row.Values) {
+		fieldValue := row.Values.GetOrNil(fieldName)
+		switch fieldValue.(type) {
+		case ResolveValue:
+			ret.ResolveFields = append(ret.ResolveFields, fieldName)
+		case Value, ValueMultiple:
+			ret.ValueFields = append(ret.ValueFields, fieldName)
+		case IsNotAValue:
+			return PlanOperation{}, NewResolveErrorf("value for table '%s' field '%s' should not be used as a field value (type %T)",
+				tableID.TableID(), fieldName, fieldValue)
+		default:
+			ret.Values[fieldName] = fieldValue
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,138 @@
+package debefix
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestPlan(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.SetTableKeyFields(tableTags, "tag_id")
+
+	goIID := data.AddWithID(tableTags, MapValues{
+		"_refid":   SetValueRefID("go"),
+		"tag_id":   ResolveValueResolve(),
+		"tag_name": "Go",
+	})
+	postIID := data.AddWithID(tablePosts, MapValues{
+		"post_id": 1,
+		"tag_id":  ValueRefID(tableTags, "go", "tag_id"),
+	})
+	data.Update(goIID.UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"tag_name": "Golang"}})
+
+	var calls []string
+	plan, err := Plan(ctx, data,
+		WithResolveOptionProcess(testProcess{name: "p1", calls: &calls}))
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(calls, 0))
+
+	assert.DeepEqual(t, []PlanOperation{
+		{
+			Type:          ResolveTypeAdd,
+			TableID:       tableTags,
+			InternalID:    goIID.InternalID,
+			RefID:         "go",
+			Values:        map[string]any{"tag_name": "Go"},
+			ResolveFields: []string{"tag_id"},
+		},
+		{
+			Type:        ResolveTypeAdd,
+			TableID:     tablePosts,
+			InternalID:  postIID.InternalID,
+			Values:      map[string]any{"post_id": 1},
+			ValueFields: []string{"tag_id"},
+		},
+		{
+			Type:            ResolveTypeUpdate,
+			TableID:         tableTags,
+			UpdateKeyFields: []string{"tag_id"},
+			InternalID:      goIID.InternalID,
+			RefID:           "go",
+			Values:          map[string]any{"tag_name": "Golang"},
+			ResolveFields:   []string{"tag_id"},
+		},
+	}, plan)
+
+	// the original data must not be changed
+	assert.Equal(t, "Go", data.Tables[tableTags.TableID()].Rows[0].Values.GetOrNil("tag_name"))
+}
+
+func TestPlanNotAValue(t *testing.T) {
+	data := NewData()
+	data.Add(tableTags, MapValues{
+		"tag_id": 1,
+		"iref":   NewInternalIDRef(tablePosts, uuid.New()),
+	})
+
+	_, err := Plan(context.Background(), data)
+	AssertIsResolveError(t, err)
+}
+
+func TestPlanChainedUpdates(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.SetTableKeyFields(tableTags, "tag_id")
+	goIID := data.AddWithID(tableTags, MapValues{
+		"_refid":   SetValueRefID("go"),
+		"tag_id":   1,
+		"tag_name": "Go",
+	})
+	data.Update(goIID.UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"tag_name": "Golang"}})
+	data.Update(goIID.UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"description": ValueFieldValue("tag_name")}})
+
+	plan, err := Plan(ctx, data)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(plan, 3))
+	assert.DeepEqual(t, map[string]any{"tag_id": 1, "tag_name": "Golang"}, plan[1].Values)
+	assert.DeepEqual(t, map[string]any{"tag_id": 1, "tag_name": "Golang"}, plan[2].Values)
+	assert.DeepEqual(t, []string{"description"}, plan[2].ValueFields)
+
+	var resolved []map[string]any
+	_, err = Resolve(ctx, data, func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+		resolved = append(resolved, maps.Collect(values.All))
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]any{"tag_id": 1, "tag_name": "Golang", "description": "Golang"}, resolved[2])
+
+	// the original data must not be changed
+	_, ok := data.Tables[tableTags.TableID()].Rows[0].Values.Get("description")
+	assert.Assert(t, !ok)
+}
+
+func TestPlanBatch(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.AddValues(tableTags,
+		MapValues{"tag_id": ResolveValueResolve(), "tag_name": "Go"},
+		MapValues{"tag_id": 2, "tag_name": "Rust"},
+		MapValues{"tag_id": 3, "tag_name": "Java"},
+	)
+
+	plan, err := Plan(ctx, data, WithResolveOptionBatchCallback(
+		func(ctx context.Context, resolveInfo ResolveInfo, values []ValuesMutable) error {
+			return nil
+		}))
+	assert.NilError(t, err)
+
+	var names []any
+	var batch []bool
+	for _, op := range plan {
+		names = append(names, op.Values["tag_name"])
+		batch = append(batch, op.Batch)
+	}
+	assert.DeepEqual(t, []any{"Rust", "Java", "Go"}, names)
+	assert.DeepEqual(t, []bool{true, true, false}, batch)
+}
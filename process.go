@@ -1,9 +1,68 @@
 package debefix
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
-// Process define processes that can run alongside a Resolve operation, like a kind of plugin.
+// Process define processes that can run alongside a Resolve or Cleanup operation, like a kind of plugin.
+// Start is called for each process in order before resolving, and Finish in reverse order after all data was
+// resolved successfully.
 type Process interface {
 	Start(ctx context.Context) (context.Context, error)
 	Finish(ctx context.Context) error
 }
+
+// ProcessAbort is a Process which is notified when the Resolve or Cleanup operation fails, so it can release any
+// resource acquired in Start, like rolling back a transaction.
+// Abort is called in reverse order for all processes that were started, with the error that caused the failure,
+// instead of Finish. This includes failures in Start of a later process, and failures in Finish of another process
+// (for the processes not finished yet).
+// Any error returned by Abort is joined to the returned error.
+type ProcessAbort interface {
+	Process
+	Abort(ctx context.Context, err error) error
+}
+
+// runProcesses starts all processes, calls f with the context returned by them, and finishes them in reverse order,
+// or aborts them if any step fails.
+func runProcesses(ctx context.Context, processes []Process, f func(ctx context.Context) error) error {
+	// start all processes
+	var started []Process
+	for _, process := range processes {
+		pctx, err := process.Start(ctx)
+		if err != nil {
+			return abortProcesses(ctx, started, err)
+		}
+		ctx = pctx
+		started = append(started, process)
+	}
+
+	err := f(ctx)
+	if err != nil {
+		return abortProcesses(ctx, started, err)
+	}
+
+	// finish all processes in reverse order
+	for i := len(started) - 1; i >= 0; i-- {
+		err = started[i].Finish(ctx)
+		if err != nil {
+			return abortProcesses(ctx, started[:i], err)
+		}
+	}
+
+	return nil
+}
+
+// abortProcesses calls Abort in reverse order for the processes which implement ProcessAbort, returning the
+// original error joined with any abort errors.
+func abortProcesses(ctx context.Context, processes []Process, err error) error {
+	for i := len(processes) - 1; i >= 0; i-- {
+		if pa, ok := processes[i].(ProcessAbort); ok {
+			if abortErr := pa.Abort(ctx, err); abortErr != nil {
+				err = errors.Join(err, abortErr)
+			}
+		}
+	}
+	return err
+}
@@ -4,7 +4,10 @@ import (
 	cmp2 "cmp"
 	"context"
 	"errors"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -17,18 +20,30 @@ type ResolveType int
 const (
 	ResolveTypeAdd ResolveType = iota
 	ResolveTypeUpdate
+	ResolveTypeDelete
 )
 
 // ResolveInfo is a context for resolve callbacks.
 type ResolveInfo struct {
-	Type            ResolveType // type of the resolve (add, update).
+	Type            ResolveType // type of the resolve (add, update, delete).
 	TableID         TableID     // table being resolved.
-	UpdateKeyFields []string    // if type is update, the names of the key fields to be used to update.
+	UpdateKeyFields []string    // if type is update or delete, the names of the key fields to be used to find the row.
+	uuidGen         *uuidGenerator
+}
+
+// NewUUID returns a new random UUID. If a UUID seed was set using WithResolveOptionUUIDSeed, the same sequence of
+// UUIDs is returned on every run.
+func (r ResolveInfo) NewUUID() uuid.UUID {
+	return r.uuidGen.New()
 }
 
 // ResolveCallback is a callback used to resolve ResolveValue values.
 type ResolveCallback func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error
 
+// ResolveBatchCallback is a callback used to resolve multiple rows of the same table at once.
+// The values will never contain ResolveValue fields.
+type ResolveBatchCallback func(ctx context.Context, resolveInfo ResolveInfo, values []ValuesMutable) error
+
 // ResolvedCallback is called for each resolved row.
 type ResolvedCallback func(ctx context.Context, resolvedData *ResolvedData, resolveInfo ResolveInfo, resolvedRow *Row) error
 
@@ -40,16 +55,103 @@ func Resolve(ctx context.Context, data *Data, resolveFunc ResolveCallback, optio
 		opt(&optns)
 	}
 
-	// start all processes
-	var err error
-	for _, process := range optns.processes {
-		ctx, err = process.Start(ctx)
+	if optns.validate {
+		err := validateData(data, optns.base)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	var resolvedData *ResolvedData
+	err := runProcesses(ctx, optns.processes, func(ctx context.Context) error {
+		var err error
+		resolvedData, err = resolve(ctx, data, resolveFunc, optns)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resolvedData, nil
+}
+
+// resolve resolves the data, without handling processes.
+func resolve(ctx context.Context, data *Data, resolveFunc ResolveCallback, optns resolveOptions) (*ResolvedData, error) {
 	resolvedData := NewResolvedData()
+	if optns.base != nil {
+		resolvedData = optns.base.clone()
+		resolvedData.Base = optns.base
+	}
+	if optns.baseTime != nil {
+		resolvedData.BaseTime = *optns.baseTime
+	} else if optns.clock != nil {
+		resolvedData.BaseTime = optns.clock.Now()
+	}
+	if optns.uuidSeed != nil {
+		resolvedData.uuidGen = newUUIDGenerator(*optns.uuidSeed)
+	}
+
+	data, layers, err := prepareResolve(data, optns)
+	if err != nil {
+		return nil, err
+	}
+
+	// tables resolved now are moved to the end of the order, so the rows added now are never deleted by Cleanup after
+	// the rows they depend on.
+	for _, layer := range layers {
+		for _, table := range layer {
+			resolvedData.TableOrder = slices.DeleteFunc(resolvedData.TableOrder, func(tableID string) bool {
+				return tableID == table.TableID.TableID()
+			})
+			resolvedData.TableOrder = append(resolvedData.TableOrder, table.TableID.TableID())
+		}
+	}
+
+	// resolve table's rows in dependency order. Tables in the same layer don't depend on each other.
+	for _, layer := range layers {
+		err := resolveLayer(ctx, resolvedData, resolveFunc, optns, layer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// global updates, done after all data was added
+	for _, update := range data.Updates {
+		err := resolveUpdate(ctx, resolvedData, resolveFunc, update)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resolvedData, nil
+}
+
+// prepareResolve returns the data to be resolved, and its tables grouped in dependency layers, where the tables of
+// each layer depend only on tables of previous layers. The tables of each layer are sorted by table id.
+// The returned data may be a modified copy of the passed one.
+func prepareResolve(data *Data, optns resolveOptions) (*Data, [][]*Table, error) {
+	// resolve only the rows matching the tag filter
+	if optns.tagFilter != nil {
+		var err error
+		data, err = filterTags(data, optns.tagFilter)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// resolve only the selected rows and their dependencies
+	if !optns.selection.isEmpty() {
+		var err error
+		data, err = selectRows(data, &optns.selection)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// break circular dependencies, to be set using updates after all rows are added
+	data, err := breakCycles(data)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// build table dependency graph
 	depg := depgraph.New()
@@ -57,128 +159,306 @@ func Resolve(ctx context.Context, data *Data, resolveFunc ResolveCallback, optio
 	for _, table := range data.Tables {
 		err := depg.DependOn(table.TableID.TableID(), "") // add blank so tables without dependencies are also returned
 		if err != nil {
-			return nil, NewResolveErrorf("error build table dependency graph: %w", err)
+			return nil, nil, NewResolveErrorf("error build table dependency graph: %w", err)
 		}
 		for _, dep := range table.Depends {
 			if table.TableID.TableID() == dep.TableID() {
 				continue
 			}
+			if _, ok := data.Tables[dep.TableID()]; !ok && optns.base != nil {
+				if _, ok := optns.base.Tables[dep.TableID()]; ok {
+					continue // table was already resolved in the base data.
+				}
+			}
 			err = depg.DependOn(table.TableID.TableID(), dep.TableID())
 			if err != nil {
-				return nil, NewResolveErrorf("error build table dependency graph: %w", err)
+				return nil, nil, NewResolveErrorf("error build table dependency graph: %w", err)
+			}
+		}
+		for _, dep := range table.BreakableDepends {
+			if !hasRowsToResolve(data, dep.TableID()) {
+				continue // breakable dependencies only order the tables which have rows being resolved.
+			}
+			err = depg.DependOn(table.TableID.TableID(), dep.TableID())
+			if err != nil {
+				return nil, nil, NewResolveErrorf("error build table dependency graph: %w", err)
 			}
 		}
 	}
 
-	var tableIDOrder []string
+	var layers [][]*Table
+	tableCount := 0
 	for _, layer := range depg.TopoSortedLayers() {
+		var tables []*Table
 		for _, layeritem := range layer {
 			if layeritem == "" {
 				continue
 			}
-			tableIDOrder = append(tableIDOrder, layeritem)
+			table, ok := data.Tables[layeritem]
+			if !ok {
+				return nil, nil, NewResolveErrorf("tableID not found: %s", layeritem)
+			}
+			tables = append(tables, table)
+		}
+		slices.SortFunc(tables, func(a, b *Table) int {
+			return cmp2.Compare(a.TableID.TableID(), b.TableID.TableID())
+		})
+		if len(tables) > 0 {
+			layers = append(layers, tables)
+			tableCount += len(tables)
 		}
 	}
 
-	if len(tableIDOrder) != len(data.Tables) {
-		return nil, NewResolveErrorf("internal error: expected to resolve %d tables but dependency graph returned only %d",
-			len(data.Tables), len(tableIDOrder))
+	if tableCount != len(data.Tables) {
+		return nil, nil, NewResolveErrorf("internal error: expected to resolve %d tables but dependency graph returned only %d",
+			len(data.Tables), tableCount)
 	}
 
-	resolvedData.TableOrder = tableIDOrder
+	return data, layers, nil
+}
 
-	// resolve table's rows in dependency order
-	for _, tableID := range tableIDOrder {
-		table, ok := data.Tables[tableID]
-		if !ok {
-			return nil, NewResolveErrorf("tableID not found: %s", tableID)
+// resolveLayer resolves the tables of one dependency layer. If the concurrency option is set, up to that number of
+// tables are resolved at the same time.
+func resolveLayer(ctx context.Context, resolvedData *ResolvedData, resolveFunc ResolveCallback, optns resolveOptions,
+	tables []*Table) error {
+	// the resolved tables are created before resolving them, so the tables map is never changed while the callbacks of
+	// other tables read it.
+	for _, table := range tables {
+		if _, ok := resolvedData.Tables[table.TableID.TableID()]; !ok && len(table.Rows) > 0 {
+			resolvedData.Tables[table.TableID.TableID()] = table.emptyCopy()
 		}
+	}
 
-		for _, row := range table.Rows {
-			resolveInfo := ResolveInfo{
-				Type:    ResolveTypeAdd,
-				TableID: table.TableID,
+	if optns.concurrency <= 1 || len(tables) <= 1 {
+		for _, table := range tables {
+			err := resolveTable(ctx, resolvedData, resolveFunc, optns, table)
+			if err != nil {
+				return err
 			}
+		}
+		return nil
+	}
 
-			// resolve the fields of this row
-			resolvedFields, err := resolveRow(ctx, resolvedData, resolveInfo, resolveFunc, row)
-			if err != nil {
-				return nil, err
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(tables))
+	sem := make(chan struct{}, optns.concurrency)
+	var wg sync.WaitGroup
+	for tableIdx, table := range tables {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[tableIdx] = resolveTable(ctx, resolvedData, resolveFunc, optns, table)
+			if errs[tableIdx] != nil {
+				cancel() // stop resolving the other tables on the first error
 			}
+		}()
+	}
+	wg.Wait()
 
-			// store resolved table row
-			if _, ok := resolvedData.Tables[tableID]; !ok {
-				resolvedData.Tables[tableID] = &Table{
-					TableID: table.TableID,
-				}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// resolveTable resolves all rows of one table.
+func resolveTable(ctx context.Context, resolvedData *ResolvedData, resolveFunc ResolveCallback, optns resolveOptions,
+	table *Table) error {
+	var batchFields map[*Row]ValuesMutable
+	if optns.batchFunc != nil {
+		var err error
+		batchFields, err = resolveTableBatch(ctx, resolvedData, optns.batchFunc, table)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, row := range table.Rows {
+		resolveInfo := ResolveInfo{
+			Type:    ResolveTypeAdd,
+			TableID: table.TableID,
+			uuidGen: resolvedData.uuidGen,
+		}
+
+		// resolve the fields of this row, if not already resolved in a batch
+		resolvedFields, ok := batchFields[row]
+		if !ok {
+			var err error
+			resolvedData.mu.RLock()
+			resolvedFields, err = resolveRowValues(ctx, resolvedData, table.TableID, row)
+			resolvedData.mu.RUnlock()
+			if err != nil {
+				return err
 			}
-			resolvedRow := &Row{
-				InternalID:        row.InternalID,
-				RefID:             row.RefID,
-				Values:            resolvedFields,
-				ResolvedCallbacks: row.ResolvedCallbacks,
+			err = resolveRowCallback(ctx, resolveInfo, resolveFunc, resolvedFields)
+			if err != nil {
+				return err
 			}
-			resolvedData.Tables[tableID].Rows = append(resolvedData.Tables[tableID].Rows, resolvedRow)
+		}
 
-			// call all row callbacks
-			for _, rowcb := range row.ResolvedCallbacks {
-				err = rowcb(ctx, resolvedData, resolveInfo, resolvedRow)
-				if err != nil {
-					return nil, err
-				}
-			}
+		err := resolveTableRowStore(ctx, resolvedData, resolveFunc, resolveInfo, table, row, resolvedFields)
+		if err != nil {
+			return err
+		}
+	}
 
-			// resolve updates
-			for _, update := range row.Updates {
-				err := resolveUpdate(ctx, resolvedData, resolveFunc, update)
-				if err != nil {
-					return nil, err
-				}
-			}
+	return nil
+}
+
+// resolveTableRowStore stores the resolved row, and calls its callbacks and updates. The resolved data lock is only
+// held while changing the data, so the callbacks of tables resolved concurrently can run at the same time.
+func resolveTableRowStore(ctx context.Context, resolvedData *ResolvedData, resolveFunc ResolveCallback,
+	resolveInfo ResolveInfo, table *Table, row *Row, resolvedFields ValuesMutable) error {
+	// store resolved table row
+	resolvedRow := &Row{
+		InternalID:        row.InternalID,
+		RefID:             row.RefID,
+		Origin:            row.Origin,
+		Values:            resolvedFields,
+		Tags:              row.Tags,
+		ResolvedCallbacks: row.ResolvedCallbacks,
+	}
+	resolvedData.mu.Lock()
+	resolvedTable := resolvedData.Tables[table.TableID.TableID()]
+	resolvedTable.Rows = append(resolvedTable.Rows, resolvedRow)
+	resolvedData.mu.Unlock()
+
+	// call all row callbacks
+	for _, rowcb := range row.ResolvedCallbacks {
+		err := rowcb(ctx, resolvedData, resolveInfo, resolvedRow)
+		if err != nil {
+			return err
 		}
 	}
 
-	// global updates, done after all data was added
-	for _, update := range data.Updates {
+	// resolve updates
+	for _, update := range row.Updates {
 		err := resolveUpdate(ctx, resolvedData, resolveFunc, update)
 		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveTableBatch resolves the rows of the table which don't depend on other rows of the same table and don't have
+// ResolveValue fields using a single batch callback call, returning their resolved fields.
+// The other rows must be resolved one at a time.
+func resolveTableBatch(ctx context.Context, resolvedData *ResolvedData, batchFunc ResolveBatchCallback,
+	table *Table) (map[*Row]ValuesMutable, error) {
+	resolveInfo := ResolveInfo{
+		Type:    ResolveTypeAdd,
+		TableID: table.TableID,
+		uuidGen: resolvedData.uuidGen,
+	}
+
+	var batchRows []*Row
+	var batchValues []ValuesMutable
+	resolvedData.mu.RLock()
+	for _, row := range table.Rows {
+		if !isBatchRow(table.TableID, row) {
+			continue
+		}
+		resolvedFields, err := resolveRowValues(ctx, resolvedData, table.TableID, row)
+		if err != nil {
+			resolvedData.mu.RUnlock()
 			return nil, err
 		}
+		batchRows = append(batchRows, row)
+		batchValues = append(batchValues, resolvedFields)
+	}
+	resolvedData.mu.RUnlock()
+
+	if len(batchRows) == 0 {
+		return nil, nil
 	}
 
-	// finish all processes
-	for _, process := range optns.processes {
-		err = process.Finish(ctx)
+	err := batchFunc(ctx, resolveInfo, batchValues)
+	if err != nil {
+		return nil, NewResolveErrorf("error resolving table '%s' rows: %w", table.TableID.TableID(), err)
+	}
+	for _, values := range batchValues {
+		err = checkResolved(resolveInfo, values)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return resolvedData, nil
+	ret := make(map[*Row]ValuesMutable, len(batchRows))
+	for idx, row := range batchRows {
+		ret[row] = batchValues[idx]
+	}
+	return ret, nil
+}
+
+// isBatchRow returns whether the row can be resolved in a batch, which means it don't have ResolveValue fields and
+// don't depend on rows of the same table.
+func isBatchRow(tableID TableID, row *Row) bool {
+	for _, fieldValue := range row.Values.All {
+		if _, ok := fieldValue.(ResolveValue); ok {
+			return false
+		}
+		if vd, ok := fieldValue.(ValueDependencies); ok {
+			for _, dep := range vd.TableDependencies() {
+				if dep.TableID() == tableID.TableID() {
+					return false
+				}
+			}
+		}
+	}
+	return true
 }
 
-// resolveUpdate resolves updated requests.
+// resolveUpdate resolves updated requests. The resolved data lock is held while finding and changing the rows, but
+// not while calling the callbacks.
 func resolveUpdate(ctx context.Context, resolvedData *ResolvedData, resolveFunc ResolveCallback, update Update) error {
+	resolvedData.mu.RLock()
 	updateData, err := update.Query.Rows(ctx, resolvedData)
+	resolvedData.mu.RUnlock()
 	if err != nil {
 		return NewResolveErrorf("error finding rows to update: %w", err)
 	}
 
 	for _, ud := range updateData {
+		resolveInfo := ResolveInfo{
+			Type:            ResolveTypeUpdate,
+			TableID:         ud.TableID,
+			UpdateKeyFields: ud.keyFields(&resolvedData.Data),
+			uuidGen:         resolvedData.uuidGen,
+		}
+
+		resolvedData.mu.Lock()
 		err := update.Action.UpdateRow(ctx, resolvedData, ud.TableID, ud.Row)
 		if err != nil {
+			resolvedData.mu.Unlock()
 			return NewResolveErrorf("error updating row: %w", err)
 		}
-		resolveInfo := ResolveInfo{
-			Type:            ResolveTypeUpdate,
-			TableID:         ud.TableID,
-			UpdateKeyFields: ud.KeyFields,
+		resolvedFields, err := resolveRowValues(ctx, resolvedData, resolveInfo.TableID, ud.Row)
+		resolvedData.mu.Unlock()
+		if err != nil {
+			return err
 		}
-		resolvedFields, err := resolveRow(ctx, resolvedData, resolveInfo, resolveFunc, ud.Row)
+
+		err = resolveRowCallback(ctx, resolveInfo, resolveFunc, resolvedFields)
 		if err != nil {
 			return err
 		}
+		resolvedData.mu.Lock()
 		ud.Row.Values = resolvedFields
+		resolvedData.mu.Unlock()
 
 		for _, rowcb := range ud.Row.ResolvedCallbacks {
 			err = rowcb(ctx, resolvedData, resolveInfo, ud.Row)
@@ -190,19 +470,6 @@ func resolveUpdate(ctx context.Context, resolvedData *ResolvedData, resolveFunc
 	return nil
 }
 
-// resolveRow resolves one row.
-func resolveRow(ctx context.Context, resolvedData *ResolvedData, resolveInfo ResolveInfo, resolveFunc ResolveCallback, row *Row) (ValuesMutable, error) {
-	resolvedFields, err := resolveRowValues(ctx, resolvedData, resolveInfo.TableID, row)
-	if err != nil {
-		return nil, err
-	}
-	err = resolveRowCallback(ctx, resolveInfo, resolveFunc, resolvedFields)
-	if err != nil {
-		return nil, err
-	}
-	return resolvedFields, nil
-}
-
 // resolveRowCallback handles the resolve callback.
 func resolveRowCallback(ctx context.Context, resolveInfo ResolveInfo,
 	resolveFunc ResolveCallback, resolvedFields ValuesMutable) error {
@@ -212,12 +479,16 @@ func resolveRowCallback(ctx context.Context, resolveInfo ResolveInfo,
 		return NewResolveErrorf("error resolving table '%s' row: %w", resolveInfo.TableID.TableID(), err)
 	}
 
+	return checkResolved(resolveInfo, resolvedFields)
+}
+
+// checkResolved returns an error if any ResolveValue was not resolved by the callback.
+func checkResolved(resolveInfo ResolveInfo, resolvedFields Values) error {
 	for fieldName, fieldValue := range resolvedFields.All {
 		if _, ok := fieldValue.(ResolveValue); ok {
 			return NewResolveErrorf("value for table '%s' field '%s' was not resolved", resolveInfo.TableID.TableID(), fieldName)
 		}
 	}
-
 	return nil
 }
 
@@ -225,8 +496,11 @@ func resolveRowCallback(ctx context.Context, resolveInfo ResolveInfo,
 func resolveRowValues(ctx context.Context, resolvedData *ResolvedData, tableID TableID, row *Row) (ValuesMutable, error) {
 	// build the fields to send to the callback.
 	// load the raw values first, so value loaders may use them.
+	// fields are resolved in sorted order, so values generated at resolve time are reproducible.
+	fieldNames := sortedFieldNames(row.Values)
 	resolvedFields := MapValues{}
-	for fieldName, fieldValue := range row.Values.All {
+	for _, fieldName := range fieldNames {
+		fieldValue := row.Values.GetOrNil(fieldName)
 		switch fieldValue.(type) {
 		case Value, ValueMultiple:
 		case IsNotAValue:
@@ -243,8 +517,8 @@ func resolveRowValues(ctx context.Context, resolvedData *ResolvedData, tableID T
 	var resolveLater []string
 	for {
 		var currentResolveLater []string
-		for fieldName, fieldValue := range row.Values.All {
-			switch vv := fieldValue.(type) {
+		for _, fieldName := range fieldNames {
+			switch vv := row.Values.GetOrNil(fieldName).(type) {
 			case Value:
 				value, ok, err := vv.ResolveValue(ctx, resolvedData, resolvedFields)
 				if errors.Is(err, ResolveLater) {
@@ -295,8 +569,119 @@ func WithResolveOptionProcess(process Process) ResolveOption {
 	}
 }
 
+// WithResolveOptionBatchCallback sets a callback to resolve multiple rows of a table in a single call, for example
+// to execute a multi-row INSERT. It receives, for each table, all rows that don't have ResolveValue fields and don't
+// depend on rows of the same table (through ValueDependencies), before the other rows of the table are resolved one
+// at a time using the ResolveCallback. The rows are stored in ResolvedData in the same order as without batching.
+func WithResolveOptionBatchCallback(batchFunc ResolveBatchCallback) ResolveOption {
+	return func(options *resolveOptions) {
+		options.batchFunc = batchFunc
+	}
+}
+
+// WithResolveOptionConcurrency resolves up to n tables at the same time, when they don't depend on each other.
+// The resolve callbacks, row callbacks (WithDataAddResolvedCallback) and updates of rows of different tables may run
+// at the same time, so they must be safe for concurrent use, for example using a database connection pool. Row
+// callbacks can read the resolved rows of the tables their row depends on, but not the rows of other tables being
+// resolved at the same time.
+// A single database transaction can't run statements concurrently, so when all statements are executed in the same
+// transaction, like with sql.TxProcess, they are executed one at a time.
+func WithResolveOptionConcurrency(n int) ResolveOption {
+	return func(options *resolveOptions) {
+		options.concurrency = n
+	}
+}
+
+// WithResolveOptionBaseTime sets the ResolvedData.BaseTime used by time values. The default is the current time.
+func WithResolveOptionBaseTime(baseTime time.Time) ResolveOption {
+	return func(options *resolveOptions) {
+		options.baseTime = &baseTime
+	}
+}
+
+// WithResolveOptionClock sets the Clock used to get the ResolvedData.BaseTime, if not set using
+// WithResolveOptionBaseTime.
+func WithResolveOptionClock(clock Clock) ResolveOption {
+	return func(options *resolveOptions) {
+		options.clock = clock
+	}
+}
+
+// WithResolveOptionUUIDSeed generates the UUIDs returned by ResolvedData.NewUUID and ResolveInfo.NewUUID, like the
+// ones from ValueGenUUID and the ones generated by ResolveCheckCallback, from a random source using the passed seed.
+//
+// Tables of the same dependency layer are resolved sorted by table id, and row fields sorted by name, so resolving
+// the same Data with the same seed and base time (WithResolveOptionBaseTime), and with internal ids generated using
+// WithDataUUIDSeed, returns the same ResolvedData on every run. This is not guaranteed when using
+// WithResolveOptionConcurrency.
+func WithResolveOptionUUIDSeed(seed int64) ResolveOption {
+	return func(options *resolveOptions) {
+		options.uuidSeed = &seed
+	}
+}
+
+// WithResolveOptionBase resolves the data on top of a previously resolved data, so its rows can reference the base
+// rows (using ValueRefID or ValueInternalID, for example) without adding them again. Tables which exist only in the
+// base data can be used as dependencies.
+// The base data is not changed, its rows are copied to the returned ResolvedData, which also uses the base BaseTime
+// unless WithResolveOptionBaseTime or WithResolveOptionClock are set. Cleanup of the returned ResolvedData only
+// deletes the rows added on top of the base data.
+func WithResolveOptionBase(base *ResolvedData) ResolveOption {
+	return func(options *resolveOptions) {
+		options.base = base
+	}
+}
+
+// WithResolveOptionTables resolves only the rows of the passed tables, and the rows they depend on, transitively.
+// Dependencies are followed through the rows referenced by field values (like ValueRefID) and updates, and through
+// table dependencies which don't reference specific rows (like the ones added with Data.AddDependencies), which
+// select all rows of the table. Global updates targeting rows which were not selected are not executed.
+// It can be used together with WithResolveOptionRefIDs.
+func WithResolveOptionTables(tableIDs ...TableID) ResolveOption {
+	return func(options *resolveOptions) {
+		options.selection.addTables(tableIDs...)
+	}
+}
+
+// WithResolveOptionRefIDs resolves only the rows of the table with the passed RefIDs, and the rows they depend on,
+// transitively, in the same way as WithResolveOptionTables.
+func WithResolveOptionRefIDs(tableID TableID, refIDs ...RefID) ResolveOption {
+	return func(options *resolveOptions) {
+		options.selection.addRefIDs(tableID, refIDs...)
+	}
+}
+
+// WithResolveOptionTagFilter resolves only the rows whose tags, including the tags of their tables, match the
+// filter. Tags are set using WithDataAddTags and Data.SetTableTags, and filters can be created using ParseTagFilter.
+// Global updates targeting rows which don't match are not executed. Rows which match but reference rows which don't
+// match cause an error to be returned.
+// It is applied before WithResolveOptionTables and WithResolveOptionRefIDs.
+func WithResolveOptionTagFilter(filter TagFilter) ResolveOption {
+	return func(options *resolveOptions) {
+		options.tagFilter = filter
+	}
+}
+
+// WithResolveOptionValidate validates the data using Data.Validate before any process or callback is called, so
+// references to missing tables and rows are reported before any row is resolved. References to the rows of the data
+// set by WithResolveOptionBase are allowed.
+func WithResolveOptionValidate() ResolveOption {
+	return func(options *resolveOptions) {
+		options.validate = true
+	}
+}
+
 type resolveOptions struct {
-	processes []Process
+	processes   []Process
+	batchFunc   ResolveBatchCallback
+	concurrency int
+	baseTime    *time.Time
+	clock       Clock
+	uuidSeed    *int64
+	base        *ResolvedData
+	selection   rowSelection
+	tagFilter   TagFilter
+	validate    bool
 }
 
 var (
@@ -316,7 +701,7 @@ func ResolveCheck(ctx context.Context, data *Data, options ...ResolveOption) err
 func ResolveCheckCallback(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
 	for fn, fv := range values.All {
 		if fresolve, ok := fv.(ResolveValue); ok {
-			frv, err := fresolve.ResolveValueParse(ctx, uuid.New())
+			frv, err := fresolve.ResolveValueParse(ctx, resolveInfo.NewUUID())
 			if err != nil {
 				return err
 			}
@@ -2,8 +2,16 @@ package debefix
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/google/uuid"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
@@ -272,3 +280,635 @@ func TestResolveNotAValue(t *testing.T) {
 		})
 	AssertIsResolveError(t, err)
 }
+
+type testProcess struct {
+	name     string
+	calls    *[]string
+	startErr error
+}
+
+func (p testProcess) Start(ctx context.Context) (context.Context, error) {
+	*p.calls = append(*p.calls, "start:"+p.name)
+	return ctx, p.startErr
+}
+
+func (p testProcess) Finish(ctx context.Context) error {
+	*p.calls = append(*p.calls, "finish:"+p.name)
+	return nil
+}
+
+func (p testProcess) Abort(ctx context.Context, err error) error {
+	*p.calls = append(*p.calls, "abort:"+p.name)
+	return nil
+}
+
+func TestResolveProcess(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.AddValues(tableTags, MapValues{"tag_id": 1})
+
+	var calls []string
+	_, err := Resolve(ctx, data,
+		func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+			return nil
+		},
+		WithResolveOptionProcess(testProcess{name: "p1", calls: &calls}),
+		WithResolveOptionProcess(testProcess{name: "p2", calls: &calls}))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"start:p1", "start:p2", "finish:p2", "finish:p1"}, calls)
+}
+
+func TestResolveProcessAbort(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.AddValues(tableTags, MapValues{"tag_id": 1})
+
+	var calls []string
+	_, err := Resolve(ctx, data,
+		func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+			return errors.New("resolve error")
+		},
+		WithResolveOptionProcess(testProcess{name: "p1", calls: &calls}),
+		WithResolveOptionProcess(testProcess{name: "p2", calls: &calls}))
+	assert.ErrorContains(t, err, "resolve error")
+	assert.DeepEqual(t, []string{"start:p1", "start:p2", "abort:p2", "abort:p1"}, calls)
+}
+
+func TestResolveProcessStartError(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.AddValues(tableTags, MapValues{"tag_id": 1})
+
+	var calls []string
+	_, err := Resolve(ctx, data,
+		func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+			return nil
+		},
+		WithResolveOptionProcess(testProcess{name: "p1", calls: &calls}),
+		WithResolveOptionProcess(testProcess{name: "p2", calls: &calls, startErr: errors.New("start error")}),
+		WithResolveOptionProcess(testProcess{name: "p3", calls: &calls}))
+	assert.ErrorContains(t, err, "start error")
+	assert.DeepEqual(t, []string{"start:p1", "start:p2", "abort:p1"}, calls)
+}
+
+func TestResolveBatch(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+
+	data.AddValues(tableTags,
+		MapValues{
+			"tag_id":   1,
+			"tag_name": "All",
+		},
+		MapValues{
+			"tag_id":   ResolveValueResolve(),
+			"_refid":   SetValueRefID("half"),
+			"tag_name": "Half",
+		},
+		MapValues{
+			"tag_id":   3,
+			"tag_name": "None",
+		},
+	)
+
+	post1IID := data.AddWithID(tablePosts,
+		MapValues{
+			"post_id": 1,
+			"tag_id":  ValueRefID(tableTags, "half", "tag_id"),
+		})
+	data.AddValues(tablePosts,
+		MapValues{
+			"post_id":        2,
+			"parent_post_id": post1IID.ValueForField("post_id"),
+		})
+
+	var batchCalls []string
+	var rowCalls []string
+
+	resolvedData, err := Resolve(ctx, data,
+		func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+			rowCalls = append(rowCalls, resolveInfo.TableID.TableID())
+			if resolveInfo.TableID.TableID() == tableTags.TableID() {
+				values.Set("tag_id", 2)
+			}
+			return nil
+		},
+		WithResolveOptionBatchCallback(func(ctx context.Context, resolveInfo ResolveInfo, values []ValuesMutable) error {
+			for range values {
+				batchCalls = append(batchCalls, resolveInfo.TableID.TableID())
+			}
+			return nil
+		}))
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, []string{tableTags.TableID(), tableTags.TableID(), tablePosts.TableID()}, batchCalls)
+	assert.DeepEqual(t, []string{tableTags.TableID(), tablePosts.TableID()}, rowCalls)
+
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{
+			"tag_id":   1,
+			"tag_name": "All",
+		},
+		{
+			"tag_id":   2,
+			"tag_name": "Half",
+		},
+		{
+			"tag_id":   3,
+			"tag_name": "None",
+		},
+	}, resolvedData.Tables[tableTags.TableID()].Rows)
+
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{
+			"post_id": 1,
+			"tag_id":  2,
+		},
+		{
+			"post_id":        2,
+			"parent_post_id": 1,
+		},
+	}, resolvedData.Tables[tablePosts.TableID()].Rows)
+}
+
+func TestResolveConcurrency(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+
+	var leafTables []TableID
+	for i := 0; i < 10; i++ {
+		leafTable := TableName(fmt.Sprintf("public.leaf_%d", i))
+		leafTables = append(leafTables, leafTable)
+		data.Add(leafTable, MapValues{
+			"_refid":  SetValueRefID("leaf"),
+			"leaf_id": ResolveValueResolve(),
+		})
+	}
+	allTable := TableName("public.all")
+	for _, leafTable := range leafTables {
+		data.Add(allTable, MapValues{
+			"leaf_id": ValueRefID(leafTable, "leaf", "leaf_id"),
+		})
+	}
+
+	var running, maxRunning atomic.Int32
+	var mu sync.Mutex
+	var leafResolved int
+
+	resolvedData, err := Resolve(ctx, data,
+		func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+			if resolveInfo.TableID.TableID() == allTable.TableID() {
+				mu.Lock()
+				defer mu.Unlock()
+				assert.Equal(t, 10, leafResolved)
+				return nil
+			}
+
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				prev := maxRunning.Load()
+				if current <= prev || maxRunning.CompareAndSwap(prev, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			leafResolved++
+			mu.Unlock()
+
+			values.Set("leaf_id", 1)
+			return nil
+		}, WithResolveOptionConcurrency(4))
+	assert.NilError(t, err)
+
+	assert.Assert(t, maxRunning.Load() > 1)
+	assert.Assert(t, maxRunning.Load() <= 4)
+	assert.Equal(t, allTable.TableID(), resolvedData.TableOrder[len(resolvedData.TableOrder)-1])
+	assert.Assert(t, is.Len(resolvedData.Tables[allTable.TableID()].Rows, 10))
+	for _, leafTable := range leafTables {
+		assert.Assert(t, is.Len(resolvedData.Tables[leafTable.TableID()].Rows, 1))
+	}
+}
+
+func TestResolveConcurrencyCallbacks(t *testing.T) {
+	ctx := context.Background()
+
+	var running, maxRunning atomic.Int32
+	callback := func(ctx context.Context, resolvedData *ResolvedData, resolveInfo ResolveInfo, resolvedRow *Row) error {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			prev := maxRunning.Load()
+			if current <= prev || maxRunning.CompareAndSwap(prev, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	data := NewData()
+	for i := 0; i < 4; i++ {
+		data.Add(TableName(fmt.Sprintf("public.leaf_%d", i)), MapValues{
+			"leaf_id": i,
+		}, WithDataAddResolvedCallback(callback))
+	}
+
+	_, err := Resolve(ctx, data, ResolveCheckCallback, WithResolveOptionConcurrency(4))
+	assert.NilError(t, err)
+	assert.Assert(t, maxRunning.Load() > 1)
+}
+
+func TestResolveConcurrencyError(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	for i := 0; i < 10; i++ {
+		data.Add(TableName(fmt.Sprintf("public.leaf_%d", i)), MapValues{
+			"leaf_id": i,
+		})
+	}
+
+	resolveErr := errors.New("resolve error")
+
+	_, err := Resolve(ctx, data,
+		func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+			if resolveInfo.TableID.TableID() == "public.leaf_5" {
+				return resolveErr
+			}
+			return nil
+		}, WithResolveOptionConcurrency(3))
+	assert.ErrorIs(t, err, resolveErr)
+}
+
+func TestResolveCycle(t *testing.T) {
+	ctx := context.Background()
+
+	tableDepartments := TableName("public.departments")
+	tableUsers := TableName("public.users")
+
+	data := NewData()
+	data.SetTableKeyFields(tableDepartments, "department_id")
+	data.SetTableKeyFields(tableUsers, "user_id")
+
+	data.Add(tableDepartments, MapValues{
+		"_refid":        SetValueRefID("sales"),
+		"department_id": 1,
+		"head_user_id":  ValueRefID(tableUsers, "mary", "user_id"),
+	})
+	data.Add(tableUsers, MapValues{
+		"_refid":        SetValueRefID("john"),
+		"user_id":       10,
+		"department_id": ValueRefID(tableDepartments, "sales", "department_id"),
+		"manager_id":    ValueRefID(tableUsers, "mary", "user_id"),
+	})
+	data.Add(tableUsers, MapValues{
+		"_refid":        SetValueRefID("mary"),
+		"user_id":       11,
+		"department_id": ValueRefID(tableDepartments, "sales", "department_id"),
+	})
+
+	type call struct {
+		resolveType ResolveType
+		tableID     string
+		keyFields   []string
+		values      map[string]any
+	}
+	var calls []call
+
+	resolvedData, err := Resolve(ctx, data,
+		func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+			calls = append(calls, call{
+				resolveType: resolveInfo.Type,
+				tableID:     resolveInfo.TableID.TableID(),
+				keyFields:   resolveInfo.UpdateKeyFields,
+				values:      maps.Collect(values.All),
+			})
+			return nil
+		})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, []call{
+		{ResolveTypeAdd, "public.departments", nil, map[string]any{"department_id": 1}},
+		{ResolveTypeAdd, "public.users", nil, map[string]any{"user_id": 10, "department_id": 1}},
+		{ResolveTypeAdd, "public.users", nil, map[string]any{"user_id": 11, "department_id": 1}},
+		{ResolveTypeUpdate, "public.departments", []string{"department_id"}, map[string]any{"department_id": 1, "head_user_id": 11}},
+		{ResolveTypeUpdate, "public.users", []string{"user_id"}, map[string]any{"user_id": 10, "department_id": 1, "manager_id": 11}},
+	}, calls, cmp.AllowUnexported(call{}))
+
+	assert.DeepEqual(t, []string{"department_id"}, resolvedData.Tables[tableDepartments.TableID()].KeyFields)
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{"department_id": 1, "head_user_id": 11},
+	}, resolvedData.Tables[tableDepartments.TableID()].Rows)
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{"user_id": 10, "department_id": 1, "manager_id": 11},
+		{"user_id": 11, "department_id": 1},
+	}, resolvedData.Tables[tableUsers.TableID()].Rows)
+
+	// the original data must not be changed
+	_, ok := data.Tables[tableDepartments.TableID()].Rows[0].Values.Get("head_user_id")
+	assert.Assert(t, ok)
+	assert.Equal(t, 0, len(data.Updates))
+}
+
+func TestResolveBreakableDependencies(t *testing.T) {
+	ctx := context.Background()
+
+	tableDepartments := TableName("public.departments")
+	tableUsers := TableName("public.users")
+	tableCountries := TableName("public.countries")
+
+	data := NewData()
+	data.AddBreakableDependencies(tableDepartments, tableUsers)
+	data.AddBreakableDependencies(tableUsers, tableDepartments, tableCountries, TableName("public.unknown"))
+	data.Add(tableUsers, MapValues{"user_id": 1, "country_id": 10})
+	data.Add(tableCountries, MapValues{"country_id": 10})
+
+	// dependencies on tables without rows are ignored, so only the dependency of departments on users is used.
+	resolvedData, err := Resolve(ctx, data, ResolveCheckCallback)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{tableCountries.TableID(), tableUsers.TableID(), tableDepartments.TableID()},
+		resolvedData.TableOrder)
+
+	// dependencies causing cycles are removed.
+	data.Add(tableDepartments, MapValues{"department_id": 1})
+	resolvedData, err = Resolve(ctx, data, ResolveCheckCallback)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{tableCountries.TableID(), tableDepartments.TableID(), tableUsers.TableID()},
+		resolvedData.TableOrder)
+
+	// explicit dependencies are always respected.
+	data.AddDependencies(tableDepartments, tableUsers)
+	resolvedData, err = Resolve(ctx, data, ResolveCheckCallback)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{tableCountries.TableID(), tableUsers.TableID(), tableDepartments.TableID()},
+		resolvedData.TableOrder)
+}
+
+func TestResolveCycleErrors(t *testing.T) {
+	ctx := context.Background()
+
+	tableDepartments := TableName("public.departments")
+	tableUsers := TableName("public.users")
+
+	newData := func() *Data {
+		data := NewData()
+		data.Add(tableDepartments, MapValues{
+			"_refid":        SetValueRefID("sales"),
+			"department_id": 1,
+			"head_user_id":  ValueRefID(tableUsers, "mary", "user_id"),
+		})
+		data.Add(tableUsers, MapValues{
+			"_refid":        SetValueRefID("mary"),
+			"user_id":       11,
+			"department_id": ValueRefID(tableDepartments, "sales", "department_id"),
+		})
+		return data
+	}
+
+	resolveFunc := func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+		return nil
+	}
+
+	// no key fields
+	_, err := Resolve(ctx, newData(), resolveFunc)
+	AssertIsResolveError(t, err)
+	assert.ErrorContains(t, err, "has no key fields")
+
+	var cycleErr *CycleError
+	assert.Assert(t, errors.As(err, &cycleErr))
+	assert.DeepEqual(t, []string{"public.departments", "public.users", "public.departments"},
+		tableIDs(cycleErr.Path))
+	assert.Assert(t, is.Len(cycleErr.Edges, 2))
+	assert.Assert(t, !cycleErr.Edges[0].Explicit)
+	assert.Assert(t, is.Len(cycleErr.Edges[0].Fields, 1))
+	assert.Equal(t, RefID("sales"), cycleErr.Edges[0].Fields[0].RefID)
+	assert.Equal(t, "head_user_id", cycleErr.Edges[0].Fields[0].FieldName)
+	assert.Equal(t, ValueDependencies(ValueRefID(tableUsers, "mary", "user_id")), cycleErr.Edges[0].Fields[0].Value)
+	assert.Equal(t, "department_id", cycleErr.Edges[1].Fields[0].FieldName)
+	assert.ErrorContains(t, err, "public.departments -> public.users -> public.departments "+
+		"[public.departments -> public.users: row 'sales' field 'head_user_id' (debefix.ValueRefIDData)] "+
+		"[public.users -> public.departments: row 'mary' field 'department_id' (debefix.ValueRefIDData)]")
+
+	// explicit dependencies
+	tableCompanies := TableName("public.companies")
+	data := newData()
+	data.SetTableKeyFields(tableDepartments, "department_id")
+	data.SetTableKeyFields(tableUsers, "user_id")
+	data.Add(tableCompanies, MapValues{"company_id": 1})
+	data.AddDependencies(tableCompanies, tableDepartments)
+	data.AddDependencies(tableDepartments, tableCompanies)
+	data.AddDependencies(tableUsers, tableCompanies)
+	_, err = Resolve(ctx, data, resolveFunc)
+	AssertIsResolveError(t, err)
+	assert.ErrorContains(t, err, "cannot be broken (it contains explicit dependencies)")
+	assert.Assert(t, errors.As(err, &cycleErr))
+	assert.DeepEqual(t, []string{"public.companies", "public.departments", "public.companies"},
+		tableIDs(cycleErr.Path))
+	assert.Assert(t, cycleErr.Edges[0].Explicit)
+	assert.Assert(t, cycleErr.Edges[1].Explicit)
+
+	// self reference without key fields
+	data = NewData()
+	data.Add(tableUsers, MapValues{
+		"user_id":    10,
+		"manager_id": ValueRefID(tableUsers, "mary", "user_id"),
+	})
+	data.Add(tableUsers, MapValues{
+		"_refid":  SetValueRefID("mary"),
+		"user_id": 11,
+	})
+	_, err = Resolve(ctx, data, resolveFunc)
+	assert.Assert(t, errors.As(err, &cycleErr))
+	assert.DeepEqual(t, []string{"public.users", "public.users"}, tableIDs(cycleErr.Path))
+	assert.Equal(t, "manager_id", cycleErr.Edges[0].Fields[0].FieldName)
+}
+
+func tableIDs(tables []TableID) []string {
+	var ret []string
+	for _, table := range tables {
+		ret = append(ret, table.TableID())
+	}
+	return ret
+}
+
+func TestResolveDeterministic(t *testing.T) {
+	ctx := context.Background()
+	baseTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	newData := func() *Data {
+		data := NewData(WithDataUUIDSeed(1))
+		for i := 0; i < 5; i++ {
+			tableID := TableName(fmt.Sprintf("public.leaf_%d", i))
+			data.Add(tableID, MapValues{
+				"_refid":     SetValueRefID("leaf"),
+				"leaf_id":    ValueGenUUID(),
+				"other_id":   ValueGenUUID(),
+				"created_at": ValueBaseTimeAdd(WithAddHours(1)),
+			})
+			data.Add(tablePosts, MapValues{
+				"post_id": ValueGenUUID(),
+				"leaf_id": ValueRefID(tableID, "leaf", "leaf_id"),
+			})
+		}
+		return data
+	}
+
+	resolveData := func() (*ResolvedData, []string) {
+		var calls []string
+		resolvedData, err := Resolve(ctx, newData(),
+			func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+				calls = append(calls, fmt.Sprintf("%s: %v", resolveInfo.TableID.TableID(), values))
+				return nil
+			},
+			WithResolveOptionBaseTime(baseTime),
+			WithResolveOptionUUIDSeed(2))
+		assert.NilError(t, err)
+		return resolvedData, calls
+	}
+
+	resolvedData1, calls1 := resolveData()
+	resolvedData2, calls2 := resolveData()
+
+	assert.DeepEqual(t, calls1, calls2)
+	assert.DeepEqual(t, resolvedData1.TableOrder, resolvedData2.TableOrder)
+	assert.DeepEqual(t, []string{"public.leaf_0", "public.leaf_1", "public.leaf_2", "public.leaf_3", "public.leaf_4",
+		"public.posts"}, resolvedData1.TableOrder)
+	assert.Equal(t, baseTime, resolvedData1.BaseTime)
+	assert.DeepEqual(t, resolvedData1.Tables, resolvedData2.Tables, cmpopts.IgnoreUnexported(Table{}))
+	assert.Equal(t, baseTime.Add(time.Hour),
+		resolvedData1.Tables["public.leaf_0"].Rows[0].Values.GetOrNil("created_at"))
+}
+
+func TestResolveCheckCallbackDeterministic(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.Add(tableTags, MapValues{
+		"tag_id": ResolveValueUUID(),
+	})
+
+	resolveTagID := func() any {
+		resolvedData, err := Resolve(ctx, data, ResolveCheckCallback, WithResolveOptionUUIDSeed(1))
+		assert.NilError(t, err)
+		return resolvedData.Tables[tableTags.TableID()].Rows[0].Values.GetOrNil("tag_id")
+	}
+
+	tagID := resolveTagID()
+	_, ok := tagID.(uuid.UUID)
+	assert.Assert(t, ok)
+	assert.Equal(t, tagID, resolveTagID())
+}
+
+func TestResolveClock(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	data := NewData()
+	data.Add(tableTags, MapValues{
+		"tag_id":     1,
+		"created_at": ValueBaseTimeAdd(WithAddDays(-30)),
+	})
+
+	resolvedData, err := Resolve(ctx, data, ResolveCheckCallback,
+		WithResolveOptionClock(ClockFunc(func() time.Time {
+			return now
+		})))
+	assert.NilError(t, err)
+	assert.Equal(t, now, resolvedData.BaseTime)
+	assert.Equal(t, now.AddDate(0, 0, -30), resolvedData.Tables[tableTags.TableID()].Rows[0].Values.GetOrNil("created_at"))
+
+	// base time has priority over the clock
+	baseTime := now.Add(time.Hour)
+	resolvedData, err = Resolve(ctx, data, ResolveCheckCallback,
+		WithResolveOptionClock(ClockFunc(func() time.Time {
+			return now
+		})),
+		WithResolveOptionBaseTime(baseTime))
+	assert.NilError(t, err)
+	assert.Equal(t, baseTime, resolvedData.BaseTime)
+}
+
+func TestResolveBase(t *testing.T) {
+	ctx := context.Background()
+
+	baseData := NewData()
+	baseData.SetTableKeyFields(tableTags, "tag_id")
+	baseData.Add(tableTags, MapValues{
+		"_refid":   SetValueRefID("go"),
+		"tag_id":   ResolveValueResolve(),
+		"tag_name": "Go",
+	})
+	baseData.Add(tablePosts, MapValues{
+		"_refid":  SetValueRefID("post_1"),
+		"post_id": 1,
+		"tag_id":  ValueRefID(tableTags, "go", "tag_id"),
+	})
+
+	baseTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	base, err := Resolve(ctx, baseData, func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+		values.Set("tag_id", 1)
+		return nil
+	}, WithResolveOptionBaseTime(baseTime))
+	assert.NilError(t, err)
+
+	data := NewData()
+	data.SetTableKeyFields(tablePostTags, "post_id", "tag_id")
+	data.Add(tablePostTags, MapValues{
+		"post_id": ValueRefID(tablePosts, "post_1", "post_id"),
+		"tag_id":  ValueRefID(tableTags, "go", "tag_id"),
+	})
+	data.Add(tableTags, MapValues{
+		"tag_id":   2,
+		"tag_name": ValueFormat("Not %s", ValueRefID(tableTags, "go", "tag_name")),
+	})
+	data.Update(ValueRefID(tableTags, "go", "tag_id").UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"tag_name": "Golang"}})
+
+	var calls []string
+	resolvedData, err := Resolve(ctx, data,
+		func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+			calls = append(calls, resolveInfo.TableID.TableID())
+			return nil
+		}, WithResolveOptionBase(base))
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, []string{tableTags.TableID(), tablePostTags.TableID(), tableTags.TableID()}, calls)
+	assert.Equal(t, base, resolvedData.Base)
+	assert.Equal(t, baseTime, resolvedData.BaseTime)
+	assert.DeepEqual(t, []string{tablePosts.TableID(), tableTags.TableID(), tablePostTags.TableID()},
+		resolvedData.TableOrder)
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{"post_id": 1, "tag_id": 1},
+	}, resolvedData.Tables[tablePostTags.TableID()].Rows)
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{"tag_id": 1, "tag_name": "Golang"},
+		{"tag_id": 2, "tag_name": "Not Go"},
+	}, resolvedData.Tables[tableTags.TableID()].Rows)
+
+	// the base data must not be changed
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{"tag_id": 1, "tag_name": "Go"},
+	}, base.Tables[tableTags.TableID()].Rows)
+	assert.DeepEqual(t, []string{tableTags.TableID(), tablePosts.TableID()}, base.TableOrder)
+
+	// only the rows added on top of the base are deleted
+	var deleted []map[string]any
+	err = Cleanup(ctx, resolvedData, func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+		deleted = append(deleted, maps.Collect(values.All))
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []map[string]any{
+		{"post_id": 1, "tag_id": 1},
+		{"tag_id": 2, "tag_name": "Not Go"},
+	}, deleted)
+}
@@ -43,6 +43,18 @@ func (r ResolveValueUUIDData) ResolveValueParse(ctx context.Context, value any)
 			return nil, NewResolveErrorf("error parsing resolved value as UUID ('%s'): %w", v, err)
 		}
 		return vv, nil
+	case []byte:
+		if len(v) == 0 && r.AllowBlank {
+			return nil, nil
+		}
+		if len(v) == 16 {
+			return uuid.FromBytes(v)
+		}
+		vv, err := uuid.ParseBytes(v)
+		if err != nil {
+			return nil, NewResolveErrorf("error parsing resolved value as UUID ('%s'): %w", string(v), err)
+		}
+		return vv, nil
 	default:
 		return nil, NewResolveErrorf("invalid type conversion to UUID: '%T'", value)
 	}
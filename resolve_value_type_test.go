@@ -0,0 +1,26 @@
+package debefix
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+)
+
+func TestResolveValueUUID(t *testing.T) {
+	ctx := context.Background()
+
+	expected := uuid.New()
+	expectedBytes, err := expected.MarshalBinary()
+	assert.NilError(t, err)
+
+	for _, value := range []any{expected, expected.String(), []byte(expected.String()), expectedBytes} {
+		rv, err := ResolveValueUUID().ResolveValueParse(ctx, value)
+		assert.NilError(t, err)
+		assert.Equal(t, expected, rv)
+	}
+
+	_, err = ResolveValueUUID().ResolveValueParse(ctx, 12)
+	AssertIsResolveError(t, err)
+}
@@ -2,6 +2,9 @@ package debefix
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -10,6 +13,9 @@ type ResolvedData struct {
 	Data
 	BaseTime   time.Time
 	TableOrder []string
+	Base       *ResolvedData // the data this one was resolved on top of, using WithResolveOptionBase.
+
+	mu sync.RWMutex // protects Data while resolving tables concurrently.
 }
 
 func NewResolvedData() *ResolvedData {
@@ -19,6 +25,32 @@ func NewResolvedData() *ResolvedData {
 	}
 }
 
+// clone returns a copy of the resolved data which can be changed without changing the original one.
+func (d *ResolvedData) clone() *ResolvedData {
+	ret := &ResolvedData{
+		Data: Data{
+			Tables: make(map[string]*Table, len(d.Tables)),
+			err:    d.err,
+		},
+		BaseTime:   d.BaseTime,
+		TableOrder: slices.Clone(d.TableOrder),
+		Base:       d.Base,
+	}
+	for tableID, table := range d.Tables {
+		newTable := table.emptyCopy()
+		newTable.Depends = slices.Clone(table.Depends)
+		newTable.BreakableDepends = slices.Clone(table.BreakableDepends)
+		newTable.Rows = make([]*Row, 0, len(table.Rows))
+		for _, row := range table.Rows {
+			newRow := *row
+			newRow.Values = MapValues(maps.Collect(row.Values.All))
+			newTable.Rows = append(newTable.Rows, &newRow)
+		}
+		ret.Tables[tableID] = newTable
+	}
+	return ret
+}
+
 // ResolveArgs resolves a list of arguments.
 // It is used by the ValueFormat value to create a string value from other values.
 func (d *ResolvedData) ResolveArgs(ctx context.Context, values Values, args ...any) ([]any, bool, error) {
@@ -6,7 +6,9 @@ import "github.com/google/uuid"
 type Row struct {
 	InternalID        uuid.UUID          // internal id that uniquely identifies this row in its table. It is randomly generated.
 	RefID             RefID              // RefID of the row, if set. Should not be duplicated in any other row of the same table.
+	Origin            string             // where the row was defined, like a fixture file position, used in error messages.
 	Values            ValuesMutable      // the row field values.
+	Tags              []string           // tags of the row, used to filter rows when resolving.
 	Updates           []Update           // updates to be done after the row is resolved.
 	ResolvedCallbacks []ResolvedCallback // a callback called after the row is resolved.
 }
@@ -0,0 +1,142 @@
+package debefix
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// TableSchema describes the columns of a table. It is registered using Data.SetTableSchema, and used to validate and
+// complete the rows when they are added.
+type TableSchema struct {
+	Columns []ColumnSchema
+}
+
+// ColumnSchema describes a column of a table.
+type ColumnSchema struct {
+	Name       string
+	Type       reflect.Type // Go type of the column values. If nil, values of any type are accepted.
+	Nullable   bool         // whether the column accepts nil values, and can be omitted from the rows.
+	HasDefault bool         // whether the database sets a value when the column is omitted from the rows.
+	Default    any          // if not nil, the value set on rows which omit the column.
+	PrimaryKey bool         // whether the column is part of the primary key, which is used as the table key fields.
+	Identity   bool         // whether the database generates the value on insert, like auto increment columns.
+	Generated  bool         // whether the value is always computed by the database, so it can't be set by rows.
+}
+
+// Column returns the column with the passed name.
+func (s *TableSchema) Column(name string) (ColumnSchema, bool) {
+	idx := slices.IndexFunc(s.Columns, func(column ColumnSchema) bool {
+		return column.Name == name
+	})
+	if idx < 0 {
+		return ColumnSchema{}, false
+	}
+	return s.Columns[idx], true
+}
+
+// PrimaryKey returns the names of the primary key columns.
+func (s *TableSchema) PrimaryKey() []string {
+	var ret []string
+	for _, column := range s.Columns {
+		if column.PrimaryKey {
+			ret = append(ret, column.Name)
+		}
+	}
+	return ret
+}
+
+// prepareRow sets the default values and the ResolveValueResolve values of identity columns omitted from the row,
+// and checks that all of its fields are valid for the table columns.
+func (s *TableSchema) prepareRow(values ValuesMutable) error {
+	var errs []error
+	hasValueMultiple := false
+	for _, fieldName := range sortedFieldNames(values) {
+		fieldValue := values.GetOrNil(fieldName)
+		if _, ok := fieldValue.(ValueMultiple); ok {
+			// ValueMultiple can set any fields, so its field name is not a column.
+			hasValueMultiple = true
+			continue
+		}
+		column, ok := s.Column(fieldName)
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown column '%s'", fieldName))
+			continue
+		}
+		if column.Generated {
+			errs = append(errs, fmt.Errorf("column '%s' is generated by the database and can't be set", fieldName))
+			continue
+		}
+		if err := column.checkValue(fieldValue); err != nil {
+			errs = append(errs, fmt.Errorf("column '%s': %w", fieldName, err))
+		}
+	}
+
+	for _, column := range s.Columns {
+		if _, ok := values.Get(column.Name); ok || column.Generated {
+			continue
+		}
+		switch {
+		case column.Default != nil:
+			values.Set(column.Name, column.Default)
+		case column.Identity:
+			values.Set(column.Name, ResolveValueResolve())
+		case !column.Nullable && !column.HasDefault && !hasValueMultiple:
+			errs = append(errs, fmt.Errorf("missing non-null column '%s'", column.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkValue checks whether a field value can be stored in the column. Values which are only known at resolve time
+// are not checked.
+func (c ColumnSchema) checkValue(value any) error {
+	switch value.(type) {
+	case Value, ResolveValue, IsNotAValue:
+		return nil
+	case nil:
+		if !c.Nullable {
+			return errors.New("nil value for non-null column")
+		}
+		return nil
+	}
+	if c.Type == nil || valueTypeMatches(reflect.TypeOf(value), c.Type) {
+		return nil
+	}
+	return fmt.Errorf("value of type %T is not compatible with column type %s", value, c.Type)
+}
+
+// valueTypeMatches returns whether a value type is compatible with a column type. Numbers are compatible with
+// numeric types of other sizes, and integers with floating point types, as fixture files don't keep Go sizes.
+func valueTypeMatches(valueType, columnType reflect.Type) bool {
+	if columnType.Kind() == reflect.Pointer {
+		columnType = columnType.Elem()
+	}
+	if valueType.AssignableTo(columnType) || columnType.Kind() == reflect.Interface && valueType.Implements(columnType) {
+		return true
+	}
+	valueKind, columnKind := typeKindGroup(valueType), typeKindGroup(columnType)
+	switch {
+	case valueKind == reflect.Int && (columnKind == reflect.Int || columnKind == reflect.Float64):
+		return true
+	case valueKind == reflect.Float64 && columnKind == reflect.Float64:
+		return true
+	}
+	return valueType.Kind() == columnType.Kind() && valueType.Kind() != reflect.Struct && valueType.ConvertibleTo(columnType)
+}
+
+// typeKindGroup returns reflect.Int for all integer types, reflect.Float64 for all floating point types, or the
+// type kind.
+func typeKindGroup(t reflect.Type) reflect.Kind {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.Int
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	default:
+		return t.Kind()
+	}
+}
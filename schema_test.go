@@ -0,0 +1,100 @@
+package debefix
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func testTagsSchema() *TableSchema {
+	return &TableSchema{
+		Columns: []ColumnSchema{
+			{Name: "tag_id", Type: reflect.TypeFor[int64](), PrimaryKey: true, Identity: true},
+			{Name: "tag_name", Type: reflect.TypeFor[string]()},
+			{Name: "description", Type: reflect.TypeFor[string](), Nullable: true},
+			{Name: "status", Type: reflect.TypeFor[string](), Default: "active"},
+			{Name: "created_at", Type: reflect.TypeFor[time.Time](), HasDefault: true},
+			{Name: "slug", Type: reflect.TypeFor[string](), Generated: true},
+		},
+	}
+}
+
+func TestTableSchema(t *testing.T) {
+	data := NewData()
+	data.SetTableSchema(tableTags, testTagsSchema())
+
+	data.Add(tableTags, MapValues{
+		"_refid":   SetValueRefID("go"),
+		"tag_name": "Go",
+	})
+	data.Add(tableTags, MapValues{
+		"tag_id":      2,
+		"tag_name":    ValueFormat("tag %d", 2),
+		"description": nil,
+		"created_at":  time.Now(),
+	})
+	assert.NilError(t, data.Err())
+
+	table := data.Tables[tableTags.TableID()]
+	assert.DeepEqual(t, []string{"tag_id"}, table.KeyFields)
+	assert.DeepEqual(t, map[string]any{
+		"tag_id":   ResolveValueResolve(),
+		"tag_name": "Go",
+		"status":   "active",
+	}, map[string]any(table.Rows[0].Values.(MapValues)))
+	assert.Equal(t, 2, table.Rows[1].Values.GetOrNil("tag_id"))
+}
+
+func TestTableSchemaInvalidRows(t *testing.T) {
+	data := NewData()
+	data.Add(tableTags, MapValues{
+		"tag_id":   1,
+		"tag_nme":  "Go",
+		"tag_name": "Go",
+	})
+	data.SetTableSchema(tableTags, testTagsSchema())
+	data.Add(tableTags, MapValues{
+		"tag_id": "2",
+		"slug":   "go",
+	}, WithDataAddOrigin("tags.yaml:5:5"))
+	data.Add(tableTags, MapValues{
+		"tag_name":    "Go",
+		"description": 12,
+		"status":      nil,
+	})
+
+	assert.Error(t, data.Err(), `row 0 of table 'public.tags' doesn't match the table schema: unknown column 'tag_nme'
+row 1 (tags.yaml:5:5) of table 'public.tags' doesn't match the table schema: column 'slug' is generated by the database and can't be set
+column 'tag_id': value of type string is not compatible with column type int64
+missing non-null column 'tag_name'
+row 2 of table 'public.tags' doesn't match the table schema: column 'description': value of type int is not compatible with column type string
+column 'status': nil value for non-null column`)
+}
+
+func TestTableSchemaUpdateKeyFields(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.SetTableSchema(tableTags, testTagsSchema())
+	goIID := data.AddWithID(tableTags, MapValues{
+		"tag_id":   1,
+		"tag_name": "Go",
+	})
+	data.Update(goIID.UpdateQuery(nil), UpdateActionSetValues{Values: MapValues{"tag_name": "Golang"}})
+	assert.NilError(t, data.Err())
+
+	var updateKeyFields [][]string
+	_, err := Resolve(ctx, data, func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+		if resolveInfo.Type == ResolveTypeUpdate {
+			updateKeyFields = append(updateKeyFields, resolveInfo.UpdateKeyFields)
+		}
+		return ResolveCheckCallback(ctx, resolveInfo, values)
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(updateKeyFields, 1))
+	assert.DeepEqual(t, []string{"tag_id"}, updateKeyFields[0])
+}
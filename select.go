@@ -0,0 +1,271 @@
+package debefix
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// rowSelection is a selection of the rows of a Data which should be resolved.
+type rowSelection struct {
+	tables map[string]bool    // tables with all rows selected.
+	refIDs map[string][]RefID // rows selected by RefID, by table.
+}
+
+func (s *rowSelection) addTables(tableIDs ...TableID) {
+	if s.tables == nil {
+		s.tables = map[string]bool{}
+	}
+	for _, tableID := range tableIDs {
+		s.tables[tableID.TableID()] = true
+	}
+}
+
+func (s *rowSelection) addRefIDs(tableID TableID, refIDs ...RefID) {
+	if s.refIDs == nil {
+		s.refIDs = map[string][]RefID{}
+	}
+	s.refIDs[tableID.TableID()] = append(s.refIDs[tableID.TableID()], refIDs...)
+}
+
+func (s *rowSelection) isEmpty() bool {
+	return len(s.tables) == 0 && len(s.refIDs) == 0
+}
+
+// selectRows returns a copy of data containing only the selected rows, and the rows they depend on, transitively.
+// Dependencies are followed through the rows referenced by field values and updates, and through table dependencies
+// which don't reference specific rows, like the ones added with Data.AddDependencies, which select all rows of the
+// table.
+// Global updates which target rows that were not selected are removed.
+func selectRows(data *Data, selection *rowSelection) (*Data, error) {
+	s := newRowSelector(data)
+
+	for _, tableID := range slices.Sorted(maps.Keys(selection.tables)) {
+		if _, ok := data.Tables[tableID]; !ok {
+			return nil, NewResolveErrorf("selected table '%s' not found", tableID)
+		}
+		s.selectTable(tableID)
+	}
+	for _, tableID := range slices.Sorted(maps.Keys(selection.refIDs)) {
+		for _, refID := range selection.refIDs[tableID] {
+			row, ok := s.refIDs[tableID][refID]
+			if !ok {
+				return nil, NewResolveErrorf("selected refID %v not found in table '%s'", refID, tableID)
+			}
+			s.selectRow(tableID, row)
+		}
+	}
+
+	return s.selectedData(s.selectUpdates()), nil
+}
+
+// selectedData returns a copy of the data containing only the selected tables and rows, and the passed updates.
+func (s *rowSelector) selectedData(updates []Update) *Data {
+	ret := &Data{
+		Tables:  make(map[string]*Table, len(s.selectedTables)),
+		Updates: updates,
+		err:     s.data.err,
+		uuidGen: s.data.uuidGen,
+	}
+	for tableID := range s.selectedTables {
+		table := s.data.Tables[tableID]
+		newTable := table.emptyCopy()
+		newTable.AddDependencies(s.graph.explicitDeps[tableID]...)
+		newTable.AddBreakableDependencies(table.BreakableDepends...)
+		for _, row := range table.Rows {
+			if s.selected[row] {
+				newTable.Rows = append(newTable.Rows, row)
+				newTable.AddDependencies(rowTableDependencies(row)...)
+			}
+		}
+		ret.Tables[tableID] = newTable
+	}
+	return ret
+}
+
+// rowSelector selects rows of a Data and the rows they depend on.
+type rowSelector struct {
+	data           *Data
+	graph          *tableGraph
+	refIDs         map[string]map[RefID]*Row
+	internalIDs    map[string]map[uuid.UUID]*Row
+	selected       map[*Row]bool
+	selectedTables map[string]bool
+	wholeTables    map[string]bool
+}
+
+func newRowSelector(data *Data) *rowSelector {
+	ret := &rowSelector{
+		data:           data,
+		graph:          newTableGraph(data),
+		refIDs:         map[string]map[RefID]*Row{},
+		internalIDs:    map[string]map[uuid.UUID]*Row{},
+		selected:       map[*Row]bool{},
+		selectedTables: map[string]bool{},
+		wholeTables:    map[string]bool{},
+	}
+	for tableID, table := range data.Tables {
+		ret.refIDs[tableID] = map[RefID]*Row{}
+		ret.internalIDs[tableID] = map[uuid.UUID]*Row{}
+		for _, row := range table.Rows {
+			ret.internalIDs[tableID][row.InternalID] = row
+			if row.RefID != "" {
+				if _, ok := ret.refIDs[tableID][row.RefID]; !ok {
+					ret.refIDs[tableID][row.RefID] = row
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// selectTable selects all rows of a table and their dependencies.
+func (s *rowSelector) selectTable(tableID string) {
+	table, ok := s.data.Tables[tableID]
+	if !ok || s.wholeTables[tableID] {
+		return
+	}
+	s.wholeTables[tableID] = true
+	s.selectedTables[tableID] = true
+	for _, dep := range s.graph.explicitDeps[tableID] {
+		s.selectTable(dep.TableID())
+	}
+	for _, row := range table.Rows {
+		s.selectRow(tableID, row)
+	}
+}
+
+// selectRow selects a row and its dependencies.
+func (s *rowSelector) selectRow(tableID string, row *Row) {
+	if s.selected[row] {
+		return
+	}
+	s.selected[row] = true
+	if !s.selectedTables[tableID] {
+		s.selectedTables[tableID] = true
+		for _, dep := range s.graph.explicitDeps[tableID] {
+			s.selectTable(dep.TableID())
+		}
+	}
+
+	for _, fieldName := range sortedFieldNames(row.Values) {
+		s.selectValue(row.Values.GetOrNil(fieldName))
+	}
+	for _, update := range row.Updates {
+		for _, ref := range updateQueryRowRefs(update.Query) {
+			s.selectRef(ref)
+		}
+		s.selectUpdateAction(update.Action)
+	}
+}
+
+// selectValue selects the rows a field value depends on.
+func (s *rowSelector) selectValue(value any) {
+	for _, ref := range valueRowRefs(value) {
+		s.selectRef(ref)
+	}
+	for _, tableID := range valueTableDependencies(value) {
+		s.selectTable(tableID.TableID())
+	}
+}
+
+// selectRef selects a referenced row, if it exists.
+func (s *rowSelector) selectRef(ref rowRef) {
+	if row := s.findRef(ref); row != nil {
+		s.selectRow(ref.tableID.TableID(), row)
+	}
+}
+
+// findRef returns a referenced row, or nil if it don't exist.
+func (s *rowSelector) findRef(ref rowRef) *Row {
+	tableID := ref.tableID.TableID()
+	if ref.refID != "" {
+		return s.refIDs[tableID][ref.refID]
+	}
+	return s.internalIDs[tableID][ref.internalID]
+}
+
+func (s *rowSelector) selectUpdateAction(action UpdateAction) {
+	if setValues, ok := action.(UpdateActionSetValues); ok && setValues.Values != nil {
+		for _, fieldName := range sortedFieldNames(setValues.Values) {
+			s.selectValue(setValues.Values.GetOrNil(fieldName))
+		}
+	}
+}
+
+// selectUpdates returns the global updates which don't target rows that were not selected, selecting the rows
+// their actions depend on. Updates targeting rows which are not in data, like rows of the base data, are kept, and
+// return an error when resolved if the rows are not found.
+func (s *rowSelector) selectUpdates() []Update {
+	for {
+		selectedCount := len(s.selected)
+		var ret []Update
+		for _, update := range s.data.Updates {
+			refs := updateQueryRowRefs(update.Query)
+			if slices.ContainsFunc(refs, func(ref rowRef) bool {
+				return s.isRefExcluded(ref)
+			}) {
+				continue
+			}
+			s.selectUpdateAction(update.Action)
+			ret = append(ret, update)
+		}
+		if len(s.selected) == selectedCount {
+			return ret
+		}
+	}
+}
+
+// isRefExcluded returns whether the referenced row exists and was not selected.
+func (s *rowSelector) isRefExcluded(ref rowRef) bool {
+	row := s.findRef(ref)
+	return row != nil && !s.selected[row]
+}
+
+// updateQueryRowRefs returns the rows targeted by an update query, if it is known to target specific rows.
+func updateQueryRowRefs(query UpdateQuery) []rowRef {
+	var queryRow QueryRow
+	switch q := query.(type) {
+	case *UpdateQueryQueryRow:
+		queryRow = q.QueryRow
+	case UpdateQueryQueryRow:
+		queryRow = q.QueryRow
+	default:
+		return nil
+	}
+	switch qr := queryRow.(type) {
+	case InternalIDRef:
+		return []rowRef{{tableID: qr.TableID, internalID: qr.InternalID}}
+	default:
+		return valueRowRefs(queryRow)
+	}
+}
+
+// valueTableDependencies returns the table dependencies of a field value which don't reference specific rows.
+func valueTableDependencies(value any) []TableID {
+	switch vv := value.(type) {
+	case ValueRefIDData, ValueInternalIDData:
+		return nil
+	case ValueDefaultData:
+		return valueTableDependencies(vv.Value)
+	case ValueFormatFuncData:
+		return valueTableDependencies(vv.Value)
+	case ValueFormatData:
+		var ret []TableID
+		for _, arg := range vv.Args {
+			ret = append(ret, valueTableDependencies(arg)...)
+		}
+		return ret
+	case ValueTemplateData:
+		var ret []TableID
+		for _, argName := range slices.Sorted(maps.Keys(vv.Args)) {
+			ret = append(ret, valueTableDependencies(vv.Args[argName])...)
+		}
+		return ret
+	case ValueDependencies:
+		return vv.TableDependencies()
+	default:
+		return nil
+	}
+}
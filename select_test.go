@@ -0,0 +1,114 @@
+package debefix
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestResolveSelect(t *testing.T) {
+	ctx := context.Background()
+
+	tableUsers := TableName("public.users")
+	tableCountries := TableName("public.countries")
+	tableComments := TableName("public.comments")
+
+	data := NewData()
+	data.Add(tableCountries, MapValues{"country_id": 1})
+	data.Add(tableCountries, MapValues{"country_id": 2})
+	data.Add(tableUsers, MapValues{"_refid": SetValueRefID("john"), "user_id": 1})
+	data.Add(tableUsers, MapValues{"_refid": SetValueRefID("mary"), "user_id": 2})
+	data.AddDependencies(tableUsers, tableCountries)
+	data.AddValues(tableTags,
+		MapValues{"_refid": SetValueRefID("go"), "tag_id": 1},
+		MapValues{"_refid": SetValueRefID("cpp"), "tag_id": 2},
+	)
+	data.AddValues(tablePosts,
+		MapValues{
+			"_refid":  SetValueRefID("post_1"),
+			"post_id": 1,
+			"tag_id":  ValueRefID(tableTags, "go", "tag_id"),
+			"title":   ValueFormat("Post by %d", ValueRefID(tableUsers, "john", "user_id")),
+		},
+		MapValues{
+			"_refid":  SetValueRefID("post_2"),
+			"post_id": 2,
+			"tag_id":  ValueRefID(tableTags, "cpp", "tag_id"),
+		},
+	)
+	data.Add(tableComments, MapValues{
+		"post_id": ValueRefID(tablePosts, "post_1", "post_id"),
+	})
+	data.Update(ValueRefID(tableTags, "go", "tag_id").UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"name": "Go"}})
+	data.Update(ValueRefID(tableTags, "cpp", "tag_id").UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"name": "C++"}})
+
+	resolvedData, err := Resolve(ctx, data, ResolveCheckCallback,
+		WithResolveOptionRefIDs(tablePosts, "post_1"))
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, []string{tableCountries.TableID(), tableTags.TableID(), tableUsers.TableID(),
+		tablePosts.TableID()}, resolvedData.TableOrder)
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{"country_id": 1},
+		{"country_id": 2},
+	}, resolvedData.Tables[tableCountries.TableID()].Rows)
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{"user_id": 1},
+	}, resolvedData.Tables[tableUsers.TableID()].Rows)
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{"tag_id": 1, "name": "Go"},
+	}, resolvedData.Tables[tableTags.TableID()].Rows)
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{"post_id": 1, "tag_id": 1, "title": "Post by 1"},
+	}, resolvedData.Tables[tablePosts.TableID()].Rows)
+
+	resolvedData, err = Resolve(ctx, data, ResolveCheckCallback,
+		WithResolveOptionTables(tableComments), WithResolveOptionRefIDs(tableTags, "cpp"))
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(resolvedData.Tables[tableTags.TableID()].Rows))
+	assert.Equal(t, 1, len(resolvedData.Tables[tablePosts.TableID()].Rows))
+	assert.Equal(t, 1, len(resolvedData.Tables[tableComments.TableID()].Rows))
+
+	_, err = Resolve(ctx, data, ResolveCheckCallback, WithResolveOptionTables(TableName("public.unknown")))
+	AssertIsResolveError(t, err)
+	assert.ErrorContains(t, err, "selected table 'public.unknown' not found")
+
+	_, err = Resolve(ctx, data, ResolveCheckCallback, WithResolveOptionRefIDs(tablePosts, "post_3"))
+	assert.ErrorContains(t, err, "selected refID post_3 not found")
+}
+
+func TestResolveSelectBase(t *testing.T) {
+	ctx := context.Background()
+
+	baseData := NewData()
+	baseData.Add(tableTags, MapValues{"_refid": SetValueRefID("go"), "tag_id": 1, "name": "Go"})
+	base, err := Resolve(ctx, baseData, ResolveCheckCallback)
+	assert.NilError(t, err)
+
+	data := NewData()
+	data.Add(tableTags, MapValues{"_refid": SetValueRefID("cpp"), "tag_id": 2, "name": "C++"})
+	data.Add(tablePosts, MapValues{"post_id": 1, "tag_id": ValueRefID(tableTags, "go", "tag_id")})
+	// global updates targeting rows of the base data are kept.
+	data.Update(ValueRefID(tableTags, "go", "tag_id").UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"name": "Golang"}})
+
+	var calls []string
+	_, err = Resolve(ctx, data,
+		func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+			calls = append(calls, resolveInfo.TableID.TableID())
+			return nil
+		}, WithResolveOptionBase(base), WithResolveOptionTables(tablePosts))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{tablePosts.TableID(), tableTags.TableID()}, calls)
+
+	// global updates targeting rows which don't exist return an error.
+	data.Update(ValueRefID(tableTags, "rust", "tag_id").UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"name": "Rust"}})
+	_, err = Resolve(ctx, data, ResolveCheckCallback, WithResolveOptionBase(base),
+		WithResolveOptionTables(tablePosts))
+	AssertIsResolveError(t, err)
+	assert.ErrorContains(t, err, "error finding rows to update")
+}
@@ -0,0 +1,30 @@
+// Package sql generates and executes SQL statements for debefix resolve callbacks.
+package sql
+
+import "strings"
+
+// ReturningStyle is how a dialect returns values generated by the database from INSERT and UPDATE statements.
+type ReturningStyle int
+
+const (
+	ReturningNone   ReturningStyle = iota // generated values cannot be returned by the statement (MySQL).
+	ReturningClause                       // "RETURNING" clause at the end of the statement (PostgreSQL, SQLite).
+	ReturningOutput                       // "OUTPUT INSERTED" clause before VALUES or WHERE (SQL Server).
+)
+
+// Dialect defines the SQL syntax differences between databases.
+type Dialect interface {
+	QuoteIdentifier(name string) string // quotes a single identifier, like a column name.
+	Placeholder(index int) string       // returns the placeholder for the argument index, starting at 1.
+	ReturningStyle() ReturningStyle     // how generated values are returned.
+	DefaultValues() string              // the clause to insert a row with only default values.
+}
+
+// QuoteName quotes a possibly qualified name, like "public.users", quoting each part separately.
+func QuoteName(dialect Dialect, name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = dialect.QuoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,329 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rrgmc/debefix/v2"
+)
+
+// Extract reads the rows of database tables and returns a Data which adds equivalent rows, which can be written as a
+// fixture file (like with fixture.WriteJSON) or as Go code (like with fixture.WriteGo).
+//
+// The tables are read using the schema returned by the introspector. All tables are read, unless tables are selected
+// using [WithExtractTable]. Rows are sorted by primary key, and each one receives a RefID made of the table name and
+// its primary key values separated by "_", like "tags_1" or "post_tags_1_2", or its position in the table if it has
+// no primary key. The "_" and "%" characters of the values are escaped as "%5F" and "%25", and if values are formatted
+// equally, a "_2", "_3" (and so on) suffix is added, so RefIDs are unique. Rows referenced by foreign keys to the same
+// table are added before the rows referencing them.
+//
+// The table key fields are set to the primary key, and foreign keys to tables which were also read are added as
+// breakable dependencies ([debefix.Data.AddBreakableDependencies]).
+//
+// Foreign key columns referencing rows which were also read are converted to [debefix.ValueRefID] values, and
+// primary key identity columns (like auto increment columns) are set to [debefix.ResolveValueResolve], so new values
+// are generated when the data is resolved. Foreign keys referencing rows which were not read keep their values.
+func Extract(ctx context.Context, qi QueryInterface, dialect Dialect, introspector Introspector,
+	options ...ExtractOption) (*debefix.Data, error) {
+	var optns extractOptions
+	for _, opt := range options {
+		opt(&optns)
+	}
+
+	tableInfos, err := introspector.Introspect(ctx, qi)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*extractTable
+	for _, tableInfo := range tableInfos {
+		et := &extractTable{info: tableInfo, indexes: map[string]map[string]debefix.RefID{}}
+		if len(optns.tables) > 0 {
+			idx := slices.IndexFunc(optns.tables, func(table extractTableOption) bool {
+				return table.tableID.TableID() == tableInfo.TableID.TableID()
+			})
+			if idx < 0 {
+				continue
+			}
+			et.where, et.args = optns.tables[idx].where, optns.tables[idx].args
+		}
+		tables = append(tables, et)
+	}
+	for _, table := range optns.tables {
+		if !slices.ContainsFunc(tables, func(et *extractTable) bool {
+			return et.info.TableID.TableID() == table.tableID.TableID()
+		}) {
+			return nil, fmt.Errorf("table '%s' not found in the database schema", table.tableID.TableID())
+		}
+	}
+
+	byTableID := map[string]*extractTable{}
+	for _, table := range tables {
+		if err := table.load(ctx, qi, dialect); err != nil {
+			return nil, err
+		}
+		byTableID[table.info.TableID.TableID()] = table
+	}
+
+	data := debefix.NewData()
+	for _, table := range tables {
+		if pk := table.info.Schema.PrimaryKey(); len(pk) > 0 {
+			data.SetTableKeyFields(table.info.TableID, pk...)
+		}
+		for _, fk := range table.info.ForeignKeys {
+			if _, ok := byTableID[fk.RefTableID.TableID()]; ok {
+				data.AddBreakableDependencies(table.info.TableID, fk.RefTableID)
+			}
+		}
+		if len(table.rows) == 0 {
+			data.AddDependencies(table.info.TableID)
+		}
+		for rowIdx, row := range table.rows {
+			values := debefix.MapValues{
+				"_refid": debefix.SetValueRefID(table.refIDs[rowIdx]),
+			}
+			for columnName, value := range row {
+				values[columnName] = value
+			}
+			for _, column := range table.info.Schema.Columns {
+				if column.PrimaryKey && column.Identity {
+					values[column.Name] = debefix.ResolveValueResolve()
+				}
+				if column.Generated {
+					delete(values, column.Name)
+				}
+			}
+			for _, fk := range table.info.ForeignKeys {
+				refTable, ok := byTableID[fk.RefTableID.TableID()]
+				if !ok {
+					continue
+				}
+				refID, ok := refTable.findRefID(fk.RefColumns, columnValues(row, fk.Columns))
+				if !ok {
+					continue
+				}
+				for idx, columnName := range fk.Columns {
+					values[columnName] = debefix.ValueRefID(refTable.info.TableID, refID, fk.RefColumns[idx])
+				}
+			}
+			data.Add(table.info.TableID, values)
+		}
+	}
+
+	return data, data.Err()
+}
+
+// ExtractOption are options for [Extract].
+type ExtractOption func(options *extractOptions)
+
+// WithExtractTable selects a table to be read by [Extract]. If where is not blank, only the rows matching the
+// condition are read, like "status = ?" using the dialect placeholders, with the passed arguments. The condition is
+// added to the query as-is.
+func WithExtractTable(tableID debefix.TableID, where string, args ...any) ExtractOption {
+	return func(options *extractOptions) {
+		options.tables = append(options.tables, extractTableOption{
+			tableID: tableID,
+			where:   where,
+			args:    args,
+		})
+	}
+}
+
+type extractOptions struct {
+	tables []extractTableOption
+}
+
+type extractTableOption struct {
+	tableID debefix.TableID
+	where   string
+	args    []any
+}
+
+// extractTable is a table being read by Extract.
+type extractTable struct {
+	info    TableInfo
+	where   string
+	args    []any
+	rows    []map[string]any
+	refIDs  []debefix.RefID
+	indexes map[string]map[string]debefix.RefID // RefIDs by column values, by column names.
+}
+
+// load reads the table rows, and generates their RefIDs.
+func (t *extractTable) load(ctx context.Context, qi QueryInterface, dialect Dialect) error {
+	query := "SELECT * FROM " + QuoteName(dialect, t.info.TableID.TableName())
+	if t.where != "" {
+		query += " WHERE " + t.where
+	}
+	pk := t.info.Schema.PrimaryKey()
+	if len(pk) > 0 {
+		query += " ORDER BY " + quoteFields(dialect, pk)
+	}
+
+	rows, err := qi.QueryContext(ctx, query, t.args...)
+	if err != nil {
+		return fmt.Errorf("error executing query '%s': %w", query, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("error reading columns of query '%s': %w", query, err)
+	}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		scan := make([]any, len(columns))
+		for idx := range values {
+			scan[idx] = &values[idx]
+		}
+		if err := rows.Scan(scan...); err != nil {
+			return fmt.Errorf("error reading rows of query '%s': %w", query, err)
+		}
+		row := map[string]any{}
+		for idx, columnName := range columns {
+			row[columnName] = t.columnValue(columnName, values[idx])
+		}
+		t.rows = append(t.rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error executing query '%s': %w", query, err)
+	}
+
+	tableName := t.info.TableID.TableName()
+	tableName = tableName[strings.LastIndex(tableName, ".")+1:]
+	used := map[debefix.RefID]bool{}
+	for rowIdx, row := range t.rows {
+		var keys []string
+		for _, columnName := range pk {
+			keys = append(keys, refIDKeyEscaper.Replace(t.refIDKey(columnName, row[columnName])))
+		}
+		if len(keys) == 0 {
+			keys = append(keys, fmt.Sprint(rowIdx+1))
+		}
+		// all RefIDs of the table have the same amount of keys, so the suffix can't be the same as another key.
+		refID := debefix.RefID(tableName + "_" + strings.Join(keys, "_"))
+		for suffix := 2; used[refID]; suffix++ {
+			refID = debefix.RefID(fmt.Sprintf("%s_%s_%d", tableName, strings.Join(keys, "_"), suffix))
+		}
+		used[refID] = true
+		t.refIDs = append(t.refIDs, refID)
+	}
+	t.sortSelfReferences()
+
+	return rows.Close()
+}
+
+// refIDKey returns the RefID key of a primary key value. Strings of columns which are not string columns, which
+// may also have values of other types, are quoted, so the string "1" is not the same as the integer 1.
+func (t *extractTable) refIDKey(columnName string, value any) string {
+	if s, ok := value.(string); ok {
+		if column, ok := t.info.Schema.Column(columnName); !ok || column.Type != reflect.TypeFor[string]() {
+			return strconv.Quote(s)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// sortSelfReferences orders the rows so the rows referenced by foreign keys to the same table come before the rows
+// referencing them, keeping the primary key order otherwise. Rows referencing each other in a cycle keep a forward
+// reference, which is set by an update when the data is resolved.
+func (t *extractTable) sortSelfReferences() {
+	var selfFKs []ForeignKey
+	for _, fk := range t.info.ForeignKeys {
+		if fk.RefTableID.TableID() == t.info.TableID.TableID() {
+			selfFKs = append(selfFKs, fk)
+		}
+	}
+	if len(selfFKs) == 0 {
+		return
+	}
+
+	position := map[debefix.RefID]int{}
+	for rowIdx, refID := range t.refIDs {
+		position[refID] = rowIdx
+	}
+	visited := make([]bool, len(t.rows))
+	rows := make([]map[string]any, 0, len(t.rows))
+	refIDs := make([]debefix.RefID, 0, len(t.refIDs))
+	var visit func(rowIdx int)
+	visit = func(rowIdx int) {
+		if visited[rowIdx] {
+			return
+		}
+		visited[rowIdx] = true
+		for _, fk := range selfFKs {
+			if refID, ok := t.findRefID(fk.RefColumns, columnValues(t.rows[rowIdx], fk.Columns)); ok {
+				visit(position[refID])
+			}
+		}
+		rows = append(rows, t.rows[rowIdx])
+		refIDs = append(refIDs, t.refIDs[rowIdx])
+	}
+	for rowIdx := range t.rows {
+		visit(rowIdx)
+	}
+	t.rows, t.refIDs = rows, refIDs
+}
+
+// refIDKeyEscaper escapes the key separator of the RefIDs in the key values.
+var refIDKeyEscaper = strings.NewReplacer("%", "%25", "_", "%5F")
+
+// columnValue converts the values returned by drivers as []byte to string, for string columns.
+func (t *extractTable) columnValue(columnName string, value any) any {
+	b, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+	if column, ok := t.info.Schema.Column(columnName); ok && column.Type == reflect.TypeFor[string]() {
+		return string(b)
+	}
+	return b
+}
+
+// findRefID returns the RefID of the row whose columns have the passed values.
+func (t *extractTable) findRefID(columns []string, values []any) (debefix.RefID, bool) {
+	indexName := strings.Join(columns, "\x00")
+	index, ok := t.indexes[indexName]
+	if !ok {
+		index = map[string]debefix.RefID{}
+		for rowIdx, row := range t.rows {
+			key, ok := extractKey(columnValues(row, columns))
+			if _, exists := index[key]; ok && !exists {
+				index[key] = t.refIDs[rowIdx]
+			}
+		}
+		t.indexes[indexName] = index
+	}
+
+	key, ok := extractKey(values)
+	if !ok {
+		return "", false
+	}
+	refID, ok := index[key]
+	return refID, ok
+}
+
+// extractKey returns a key for the values, including their types, or false if any of them is nil.
+func extractKey(values []any) (string, bool) {
+	var ret []string
+	for _, value := range values {
+		if value == nil {
+			return "", false
+		}
+		ret = append(ret, fmt.Sprintf("%T\x01%v", value, value))
+	}
+	return strings.Join(ret, "\x00"), true
+}
+
+// columnValues returns the values of the row columns.
+func columnValues(row map[string]any, columns []string) []any {
+	var ret []any
+	for _, columnName := range columns {
+		ret = append(ret, row[columnName])
+	}
+	return ret
+}
@@ -0,0 +1,209 @@
+package sql_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rrgmc/debefix/v2"
+	"github.com/rrgmc/debefix/v2/fixture"
+	"github.com/rrgmc/debefix/v2/sql"
+	"github.com/rrgmc/debefix/v2/sql/sqlite"
+	"gotest.tools/v3/assert"
+)
+
+func TestExtract(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	_, err := db.Exec(`
+insert into tags (tag_id, name) values (1, 'Go'), (2, 'JavaScript');
+insert into posts (post_id, tag_id, title) values ('p1', 1, 'First post'), ('p2', 2, 'Second post'),
+	('p3', 1, 'Third post');
+`)
+	assert.NilError(t, err)
+
+	data, err := sql.Extract(ctx, db, sqlite.Dialect(), sqlite.Introspector(),
+		sql.WithExtractTable(tableSQLTags, "tag_id = ?", 1),
+		sql.WithExtractTable(tableSQLPosts, ""))
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, []string{"tag_id"}, data.Tables["tags"].KeyFields)
+	assert.Equal(t, debefix.RefID("tags_1"), data.Tables["tags"].Rows[0].RefID)
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{
+			"tag_id": debefix.ResolveValueResolve(),
+			"name":   "Go",
+		},
+	}, data.Tables["tags"].Rows)
+	debefix.AssertRowValuesDeepEqual(t, []map[string]any{
+		{
+			"post_id": "p1",
+			"tag_id":  debefix.ValueRefID(tableSQLTags, "tags_1", "tag_id"),
+			"title":   "First post",
+		},
+		{
+			"post_id": "p2",
+			"tag_id":  int64(2), // tag not extracted.
+			"title":   "Second post",
+		},
+		{
+			"post_id": "p3",
+			"tag_id":  debefix.ValueRefID(tableSQLTags, "tags_1", "tag_id"),
+			"title":   "Third post",
+		},
+	}, data.Tables["posts"].Rows)
+
+	var buf bytes.Buffer
+	err = fixture.WriteGo(&buf, data, "fixtures")
+	assert.NilError(t, err)
+	assert.Assert(t, bytes.Contains(buf.Bytes(), []byte(`"_refid":  debefix.SetValueRefID("posts_p1"),`)))
+
+	// the extracted data can be resolved into another database.
+	buf.Reset()
+	err = fixture.WriteJSON(&buf, data)
+	assert.NilError(t, err)
+
+	loaded := debefix.NewData()
+	err = fixture.LoadJSON(loaded, &buf)
+	assert.NilError(t, err)
+
+	newDB := openTestDB(t)
+	_, err = newDB.Exec(`insert into tags (tag_id, name) values (2, 'JavaScript')`)
+	assert.NilError(t, err)
+
+	_, err = debefix.Resolve(ctx, loaded, sqlite.ResolveFunc(newDB))
+	assert.NilError(t, err)
+
+	var count int
+	err = newDB.QueryRow(`select count(*) from posts p join tags t on t.tag_id = p.tag_id where t.name = 'Go'`).
+		Scan(&count)
+	assert.NilError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestExtractRefIDs(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	_, err := db.Exec(`
+create table pairs (
+	a text not null,
+	b text not null,
+	primary key (a, b)
+);
+insert into pairs (a, b) values ('x_y', 'z'), ('x', 'y_z'), ('100%', 'z');
+create table codes (
+	code primary key
+);
+insert into codes (code) values (1), ('1');
+`)
+	assert.NilError(t, err)
+
+	data, err := sql.Extract(ctx, db, sqlite.Dialect(), sqlite.Introspector(),
+		sql.WithExtractTable(debefix.TableName("pairs"), ""),
+		sql.WithExtractTable(debefix.TableName("codes"), ""))
+	assert.NilError(t, err)
+
+	var refIDs []debefix.RefID
+	for _, tableID := range []string{"pairs", "codes"} {
+		for _, row := range data.Tables[tableID].Rows {
+			refIDs = append(refIDs, row.RefID)
+		}
+	}
+	assert.DeepEqual(t, []debefix.RefID{"pairs_100%25_z", "pairs_x_y%5Fz", "pairs_x%5Fy_z", "codes_1", `codes_"1"`},
+		refIDs)
+}
+
+func TestExtractSelfReference(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	_, err := db.Exec(`
+create table emp (
+	emp_id integer primary key,
+	manager_id integer references emp (emp_id)
+);
+insert into emp (emp_id, manager_id) values (1, 2), (2, null), (3, 4), (4, 3);
+`)
+	assert.NilError(t, err)
+
+	data, err := sql.Extract(ctx, db, sqlite.Dialect(), sqlite.Introspector(),
+		sql.WithExtractTable(debefix.TableName("emp"), ""))
+	assert.NilError(t, err)
+
+	var refIDs []debefix.RefID
+	for _, row := range data.Tables["emp"].Rows {
+		refIDs = append(refIDs, row.RefID)
+	}
+	assert.DeepEqual(t, []debefix.RefID{"emp_2", "emp_1", "emp_4", "emp_3"}, refIDs)
+
+	// rows referencing each other are added by breaking the cycle.
+	newDB := openTestDB(t)
+	_, err = newDB.Exec(`create table emp (emp_id integer primary key, manager_id integer references emp (emp_id))`)
+	assert.NilError(t, err)
+	_, err = debefix.Resolve(ctx, data, sqlite.ResolveFunc(newDB))
+	assert.NilError(t, err)
+
+	var count int
+	err = newDB.QueryRow(`select count(*) from emp e join emp m on m.emp_id = e.manager_id`).Scan(&count)
+	assert.NilError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestExtractCycleJSON(t *testing.T) {
+	ctx := context.Background()
+	schema := `
+create table departments (
+	department_id integer primary key,
+	head_user_id integer references users (user_id)
+);
+create table users (
+	user_id integer primary key,
+	department_id integer references departments (department_id)
+);
+`
+	db := openTestDB(t)
+	_, err := db.Exec(schema + `
+insert into departments (department_id, head_user_id) values (1, null);
+insert into users (user_id, department_id) values (10, 1);
+update departments set head_user_id = 10;
+`)
+	assert.NilError(t, err)
+
+	data, err := sql.Extract(ctx, db, sqlite.Dialect(), sqlite.Introspector(),
+		sql.WithExtractTable(debefix.TableName("departments"), ""),
+		sql.WithExtractTable(debefix.TableName("users"), ""))
+	assert.NilError(t, err)
+
+	var buf bytes.Buffer
+	err = fixture.WriteJSON(&buf, data)
+	assert.NilError(t, err)
+
+	loaded := debefix.NewData()
+	err = fixture.LoadJSON(loaded, &buf)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"department_id"}, loaded.Tables["departments"].KeyFields)
+	assert.DeepEqual(t, []debefix.TableID{debefix.TableName("users")}, loaded.Tables["departments"].BreakableDepends)
+
+	newDB := openTestDB(t)
+	_, err = newDB.Exec(schema)
+	assert.NilError(t, err)
+	_, err = debefix.Resolve(ctx, loaded, sqlite.ResolveFunc(newDB))
+	assert.NilError(t, err)
+
+	// the identity columns have new values.
+	var count int
+	err = newDB.QueryRow(`select count(*) from departments d
+		join users u on u.user_id = d.head_user_id and u.department_id = d.department_id`).Scan(&count)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestExtractTableNotFound(t *testing.T) {
+	db := openTestDB(t)
+
+	_, err := sql.Extract(context.Background(), db, sqlite.Dialect(), sqlite.Introspector(),
+		sql.WithExtractTable(debefix.TableName("users"), ""))
+	assert.ErrorContains(t, err, "table 'users' not found")
+}
@@ -0,0 +1,38 @@
+// Package mysql implements the MySQL SQL dialect.
+package mysql
+
+import (
+	"strings"
+
+	"github.com/rrgmc/debefix/v2"
+	"github.com/rrgmc/debefix/v2/sql"
+)
+
+// Dialect returns the MySQL SQL dialect. MySQL don't support returning generated values from statements, so only
+// a single auto increment field can be resolved, using the last insert id.
+func Dialect() sql.Dialect {
+	return dialect{}
+}
+
+// ResolveFunc returns a [debefix.ResolveCallback] which executes MySQL statements using [sql.ResolveFunc].
+func ResolveFunc(qi sql.QueryInterface) debefix.ResolveCallback {
+	return sql.ResolveFunc(qi, Dialect())
+}
+
+type dialect struct{}
+
+func (d dialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (d dialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (d dialect) ReturningStyle() sql.ReturningStyle {
+	return sql.ReturningNone
+}
+
+func (d dialect) DefaultValues() string {
+	return "() VALUES ()"
+}
@@ -0,0 +1,30 @@
+package mysql
+
+import "github.com/rrgmc/debefix/v2/sql"
+
+// Introspector returns a [sql.Introspector] which reads the schema of the tables of the current MySQL database,
+// using the information_schema tables.
+func Introspector() sql.Introspector {
+	return sql.QueryIntrospector{
+		Columns: `
+select c.table_name, c.column_name, c.column_type,
+	case when c.is_nullable = 'YES' then 1 else 0 end,
+	case when c.column_default is not null then 1 else 0 end,
+	case when c.extra like '%auto_increment%' then 1 else 0 end,
+	case when c.extra like '%VIRTUAL GENERATED%' or c.extra like '%STORED GENERATED%' then 1 else 0 end
+from information_schema.columns c
+join information_schema.tables t on t.table_schema = c.table_schema and t.table_name = c.table_name
+where c.table_schema = database() and t.table_type = 'BASE TABLE'
+order by c.table_name, c.ordinal_position`,
+		PrimaryKeys: `
+select table_name, column_name
+from information_schema.key_column_usage
+where table_schema = database() and constraint_name = 'PRIMARY'
+order by table_name, ordinal_position`,
+		ForeignKeys: `
+select table_name, constraint_name, column_name, referenced_table_name, referenced_column_name
+from information_schema.key_column_usage
+where table_schema = database() and referenced_table_name is not null
+order by table_name, constraint_name, ordinal_position`,
+	}
+}
@@ -0,0 +1,38 @@
+// Package postgres implements the PostgreSQL SQL dialect.
+package postgres
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rrgmc/debefix/v2"
+	"github.com/rrgmc/debefix/v2/sql"
+)
+
+// Dialect returns the PostgreSQL SQL dialect.
+func Dialect() sql.Dialect {
+	return dialect{}
+}
+
+// ResolveFunc returns a [debefix.ResolveCallback] which executes PostgreSQL statements using [sql.ResolveFunc].
+func ResolveFunc(qi sql.QueryInterface) debefix.ResolveCallback {
+	return sql.ResolveFunc(qi, Dialect())
+}
+
+type dialect struct{}
+
+func (d dialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (d dialect) Placeholder(index int) string {
+	return "$" + strconv.Itoa(index)
+}
+
+func (d dialect) ReturningStyle() sql.ReturningStyle {
+	return sql.ReturningClause
+}
+
+func (d dialect) DefaultValues() string {
+	return "DEFAULT VALUES"
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import "github.com/rrgmc/debefix/v2/sql"
+
+// Introspector returns a [sql.Introspector] which reads the schema of the tables of a PostgreSQL schema, like
+// "public", using the information_schema tables. The table ids are qualified with the schema name, like
+// "public.users".
+func Introspector(schema string) sql.Introspector {
+	return sql.QueryIntrospector{
+		Columns: `
+select c.table_schema || '.' || c.table_name, c.column_name, c.data_type,
+	case when c.is_nullable = 'YES' then 1 else 0 end,
+	case when c.column_default is not null then 1 else 0 end,
+	case when c.is_identity = 'YES' or c.column_default like 'nextval(%' then 1 else 0 end,
+	case when c.is_generated = 'ALWAYS' then 1 else 0 end
+from information_schema.columns c
+join information_schema.tables t on t.table_schema = c.table_schema and t.table_name = c.table_name
+where c.table_schema = $1 and t.table_type = 'BASE TABLE'
+order by c.table_name, c.ordinal_position`,
+		PrimaryKeys: `
+select kcu.table_schema || '.' || kcu.table_name, kcu.column_name
+from information_schema.table_constraints tc
+join information_schema.key_column_usage kcu on kcu.constraint_schema = tc.constraint_schema and
+	kcu.constraint_name = tc.constraint_name and kcu.table_name = tc.table_name
+where tc.constraint_type = 'PRIMARY KEY' and tc.table_schema = $1
+order by kcu.table_name, kcu.ordinal_position`,
+		ForeignKeys: `
+select kcu.table_schema || '.' || kcu.table_name, kcu.constraint_name, kcu.column_name,
+	rkcu.table_schema || '.' || rkcu.table_name, rkcu.column_name
+from information_schema.referential_constraints rc
+join information_schema.key_column_usage kcu on kcu.constraint_schema = rc.constraint_schema and
+	kcu.constraint_name = rc.constraint_name
+join information_schema.key_column_usage rkcu on rkcu.constraint_schema = rc.unique_constraint_schema and
+	rkcu.constraint_name = rc.unique_constraint_name and rkcu.ordinal_position = kcu.position_in_unique_constraint
+where kcu.table_schema = $1
+order by kcu.table_name, kcu.constraint_name, kcu.ordinal_position`,
+		Args: []any{schema},
+	}
+}
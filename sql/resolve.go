@@ -0,0 +1,244 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rrgmc/debefix/v2"
+)
+
+// QueryInterface executes SQL statements. It is implemented by [sql.DB], [sql.Tx] and [sql.Conn].
+type QueryInterface interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// ResolveFunc returns a [debefix.ResolveCallback] which executes the statements built by [BuildStatement], and sets
+// the values returned by the database to the fields containing a [debefix.ResolveValue], after parsing them with
+// [debefix.ResolveValue.ResolveValueParse].
+//
+// If a transaction was started by a [TxProcess], it is used instead of qi. As a transaction can't execute statements
+// concurrently, its statements are executed one at a time, even when using [debefix.WithResolveOptionConcurrency].
+//
+// For dialects which don't support returning values, a single field can be resolved on inserts using the
+// last insert id.
+func ResolveFunc(qi QueryInterface, dialect Dialect) debefix.ResolveCallback {
+	return func(ctx context.Context, resolveInfo debefix.ResolveInfo, values debefix.ValuesMutable) error {
+		stmt, err := BuildStatement(dialect, resolveInfo, values)
+		if err != nil {
+			return err
+		}
+
+		execQI, unlock := contextQueryInterface(ctx, qi)
+		defer unlock()
+
+		var returned []any
+		switch {
+		case len(stmt.Returning) == 0:
+			_, err = execQI.ExecContext(ctx, stmt.Query, stmt.Args...)
+			if err != nil {
+				return fmt.Errorf("error executing query '%s': %w", stmt.Query, err)
+			}
+			return nil
+		case dialect.ReturningStyle() == ReturningNone:
+			returned, err = execLastInsertID(ctx, execQI, resolveInfo, stmt)
+		default:
+			returned, err = queryReturning(ctx, execQI, stmt)
+		}
+		if err != nil {
+			return err
+		}
+
+		for idx, fieldName := range stmt.Returning {
+			resolveValue, ok := values.GetOrNil(fieldName).(debefix.ResolveValue)
+			if !ok {
+				return fmt.Errorf("field '%s' is not a ResolveValue", fieldName)
+			}
+			value, err := resolveValue.ResolveValueParse(ctx, returned[idx])
+			if err != nil {
+				return fmt.Errorf("error parsing returned value of field '%s': %w", fieldName, err)
+			}
+			values.Set(fieldName, value)
+		}
+
+		return nil
+	}
+}
+
+// batchMaxArgs is the maximum number of arguments of each batch statement, which is supported by all databases.
+const batchMaxArgs = 999
+
+// ResolveBatchFunc returns a [debefix.ResolveBatchCallback] which inserts the rows using the multi-row INSERT
+// statements built by [BuildInsertBatch], each with at most 999 arguments.
+//
+// If a transaction was started by a [TxProcess], it is used instead of qi, executing one statement at a time.
+func ResolveBatchFunc(qi QueryInterface, dialect Dialect) debefix.ResolveBatchCallback {
+	return func(ctx context.Context, resolveInfo debefix.ResolveInfo, values []debefix.ValuesMutable) error {
+		stmts, err := BuildInsertBatch(dialect, resolveInfo.TableID, values, batchMaxArgs)
+		if err != nil {
+			return err
+		}
+
+		execQI, unlock := contextQueryInterface(ctx, qi)
+		defer unlock()
+
+		for _, stmt := range stmts {
+			_, err = execQI.ExecContext(ctx, stmt.Query, stmt.Args...)
+			if err != nil {
+				return fmt.Errorf("error executing query '%s': %w", stmt.Query, err)
+			}
+		}
+		return nil
+	}
+}
+
+// queryReturning executes a query which returns one row with the values of the returning fields.
+func queryReturning(ctx context.Context, qi QueryInterface, stmt Statement) ([]any, error) {
+	rows, err := qi.QueryContext(ctx, stmt.Query, stmt.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query '%s': %w", stmt.Query, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error executing query '%s': %w", stmt.Query, err)
+		}
+		return nil, fmt.Errorf("query '%s' returned no rows", stmt.Query)
+	}
+
+	returned := make([]any, len(stmt.Returning))
+	scan := make([]any, len(stmt.Returning))
+	for idx := range returned {
+		scan[idx] = &returned[idx]
+	}
+	if err := rows.Scan(scan...); err != nil {
+		return nil, fmt.Errorf("error reading returned values of query '%s': %w", stmt.Query, err)
+	}
+	return returned, rows.Close()
+}
+
+// execLastInsertID executes a query and returns its last insert id as the single returning field.
+func execLastInsertID(ctx context.Context, qi QueryInterface, resolveInfo debefix.ResolveInfo, stmt Statement) ([]any, error) {
+	if resolveInfo.Type != debefix.ResolveTypeAdd || len(stmt.Returning) != 1 {
+		return nil, fmt.Errorf("dialect can only resolve a single field on inserts, resolving %v in table '%s'",
+			stmt.Returning, resolveInfo.TableID.TableID())
+	}
+
+	result, err := qi.ExecContext(ctx, stmt.Query, stmt.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query '%s': %w", stmt.Query, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert id of query '%s': %w", stmt.Query, err)
+	}
+	return []any{id}, nil
+}
+
+// TxProcess is a [debefix.Process] which starts a transaction on Start, commits it on Finish, and rolls it back
+// on Abort if Resolve fails. The transaction is stored in the context, and used by [ResolveFunc].
+//
+//	_, err := debefix.Resolve(ctx, data, sql.ResolveFunc(db, postgres.Dialect()),
+//		debefix.WithResolveOptionProcess(sql.NewTxProcess(db)))
+type TxProcess struct {
+	db     *sql.DB
+	txOpts *sql.TxOptions
+	tx     *sql.Tx
+}
+
+var _ debefix.ProcessAbort = (*TxProcess)(nil)
+
+// NewTxProcess creates a new TxProcess.
+func NewTxProcess(db *sql.DB, options ...TxProcessOption) *TxProcess {
+	ret := &TxProcess{
+		db: db,
+	}
+	for _, opt := range options {
+		opt(ret)
+	}
+	return ret
+}
+
+// Start begins the transaction and returns a context containing it.
+func (p *TxProcess) Start(ctx context.Context) (context.Context, error) {
+	tx, err := p.db.BeginTx(ctx, p.txOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	p.tx = tx
+	return ContextWithTx(ctx, tx), nil
+}
+
+// Finish commits the transaction.
+func (p *TxProcess) Finish(ctx context.Context) error {
+	if p.tx == nil {
+		return errors.New("transaction was not started")
+	}
+	if err := p.tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// Abort rolls back the transaction.
+func (p *TxProcess) Abort(ctx context.Context, err error) error {
+	return p.Rollback()
+}
+
+// Rollback rolls back the transaction. It does nothing if the transaction was not started or was already finished.
+func (p *TxProcess) Rollback() error {
+	if p.tx == nil {
+		return nil
+	}
+	err := p.tx.Rollback()
+	if err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return fmt.Errorf("error rolling back transaction: %w", err)
+	}
+	return nil
+}
+
+// TxProcessOption are options for [NewTxProcess].
+type TxProcessOption func(*TxProcess)
+
+// WithTxProcessTxOptions sets the options used to begin the transaction.
+func WithTxProcessTxOptions(txOpts *sql.TxOptions) TxProcessOption {
+	return func(p *TxProcess) {
+		p.txOpts = txOpts
+	}
+}
+
+type txContextKey struct{}
+
+// txContext is the transaction stored in the context, with a lock to use it from concurrent callbacks.
+type txContext struct {
+	tx *sql.Tx
+	mu sync.Mutex
+}
+
+// ContextWithTx returns a context containing the transaction, which will be used by [ResolveFunc].
+func ContextWithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, &txContext{tx: tx})
+}
+
+// TxFromContext returns the transaction stored in the context, or nil if none.
+func TxFromContext(ctx context.Context) *sql.Tx {
+	if txc, ok := ctx.Value(txContextKey{}).(*txContext); ok {
+		return txc.tx
+	}
+	return nil
+}
+
+// contextQueryInterface returns the transaction stored in the context, locked until the returned function is called,
+// or qi if none.
+func contextQueryInterface(ctx context.Context, qi QueryInterface) (QueryInterface, func()) {
+	txc, ok := ctx.Value(txContextKey{}).(*txContext)
+	if !ok || txc.tx == nil {
+		return qi, func() {}
+	}
+	txc.mu.Lock()
+	return txc.tx, txc.mu.Unlock
+}
@@ -0,0 +1,224 @@
+package sql_test
+
+import (
+	"context"
+	gosql "database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rrgmc/debefix/v2"
+	"github.com/rrgmc/debefix/v2/sql"
+	"github.com/rrgmc/debefix/v2/sql/sqlite"
+	"gotest.tools/v3/assert"
+	_ "modernc.org/sqlite"
+)
+
+var (
+	tableSQLTags  = debefix.TableName("tags")
+	tableSQLPosts = debefix.TableName("posts")
+)
+
+func openTestDB(t *testing.T) *gosql.DB {
+	db, err := gosql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = db.Exec(`
+create table tags (
+	tag_id integer primary key autoincrement,
+	name text not null
+);
+create table posts (
+	post_id text primary key default (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' ||
+		substr(hex(randomblob(2)), 2) || '-a' || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+	tag_id integer not null references tags (tag_id),
+	title text not null
+);
+`)
+	assert.NilError(t, err)
+	return db
+}
+
+func testSQLData() *debefix.Data {
+	data := debefix.NewData()
+	data.AddValues(tableSQLTags,
+		debefix.MapValues{
+			"tag_id": debefix.ResolveValueResolve(),
+			"_refid": debefix.SetValueRefID("go"),
+			"name":   "Go",
+		},
+	)
+	data.AddValues(tableSQLPosts,
+		debefix.MapValues{
+			"post_id": debefix.ResolveValueUUID(),
+			"tag_id":  debefix.ValueRefID(tableSQLTags, "go", "tag_id"),
+			"title":   "First post",
+		},
+	)
+	return data
+}
+
+func TestResolveFunc(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	resolvedData, err := debefix.Resolve(ctx, testSQLData(), sqlite.ResolveFunc(db))
+	assert.NilError(t, err)
+
+	tagID := resolvedData.Tables[tableSQLTags.TableID()].Rows[0].Values.GetOrNil("tag_id")
+	assert.Equal(t, int64(1), tagID)
+
+	postRow := resolvedData.Tables[tableSQLPosts.TableID()].Rows[0]
+	postID, ok := postRow.Values.GetOrNil("post_id").(uuid.UUID)
+	assert.Assert(t, ok, "post_id should be an UUID")
+	assert.Equal(t, tagID, postRow.Values.GetOrNil("tag_id"))
+
+	var dbTitle string
+	err = db.QueryRow("select title from posts where post_id = ? and tag_id = ?", postID.String(), tagID).Scan(&dbTitle)
+	assert.NilError(t, err)
+	assert.Equal(t, "First post", dbTitle)
+}
+
+func TestResolveFuncUpdate(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	data := testSQLData()
+	data.Update(debefix.ValueRefID(tableSQLTags, "go", "tag_id").UpdateQuery([]string{"tag_id"}),
+		debefix.UpdateActionSetValues{Values: debefix.MapValues{"name": "Golang"}})
+
+	_, err := debefix.Resolve(ctx, data, sqlite.ResolveFunc(db))
+	assert.NilError(t, err)
+
+	var dbName string
+	err = db.QueryRow("select name from tags where tag_id = 1").Scan(&dbName)
+	assert.NilError(t, err)
+	assert.Equal(t, "Golang", dbName)
+}
+
+func TestCleanup(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	_, err := db.Exec("insert into tags (name) values ('Existing')")
+	assert.NilError(t, err)
+
+	data := testSQLData()
+	data.SetTableKeyFields(tableSQLTags, "tag_id")
+	data.SetTableKeyFields(tableSQLPosts, "post_id")
+
+	resolvedData, err := debefix.Resolve(ctx, data, sqlite.ResolveFunc(db))
+	assert.NilError(t, err)
+
+	err = debefix.Cleanup(ctx, resolvedData, sqlite.ResolveFunc(db),
+		debefix.WithCleanupOptionProcess(sql.NewTxProcess(db)))
+	assert.NilError(t, err)
+
+	var count int
+	err = db.QueryRow("select count(*) from posts").Scan(&count)
+	assert.NilError(t, err)
+	assert.Equal(t, 0, count)
+
+	var name string
+	err = db.QueryRow("select name from tags").Scan(&name)
+	assert.NilError(t, err)
+	assert.Equal(t, "Existing", name)
+}
+
+func TestTxProcess(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	_, err := debefix.Resolve(ctx, testSQLData(), sqlite.ResolveFunc(db),
+		debefix.WithResolveOptionProcess(sql.NewTxProcess(db)))
+	assert.NilError(t, err)
+
+	var count int
+	err = db.QueryRow("select count(*) from posts").Scan(&count)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestTxProcessRollback(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	data := testSQLData()
+	data.AddValues(tableSQLPosts,
+		debefix.MapValues{
+			"tag_id": debefix.ValueErr{Err: errors.New("test error")},
+			"title":  "Invalid post",
+		},
+	)
+
+	_, err := debefix.Resolve(ctx, data, sqlite.ResolveFunc(db),
+		debefix.WithResolveOptionProcess(sql.NewTxProcess(db)))
+	assert.ErrorContains(t, err, "test error")
+
+	var count int
+	err = db.QueryRow("select count(*) from tags").Scan(&count)
+	assert.NilError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestTxProcessConcurrency(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	data := debefix.NewData()
+	for i := 0; i < 5; i++ {
+		tableID := debefix.TableName(fmt.Sprintf("items_%d", i))
+		_, err := db.Exec(fmt.Sprintf("create table %s (item_id integer primary key autoincrement, name text not null)",
+			tableID.TableName()))
+		assert.NilError(t, err)
+		for j := 0; j < 10; j++ {
+			data.Add(tableID, debefix.MapValues{
+				"item_id": debefix.ResolveValueResolve(),
+				"name":    fmt.Sprintf("item %d", j),
+			})
+		}
+	}
+
+	resolvedData, err := debefix.Resolve(ctx, data, sqlite.ResolveFunc(db),
+		debefix.WithResolveOptionProcess(sql.NewTxProcess(db)),
+		debefix.WithResolveOptionConcurrency(5))
+	assert.NilError(t, err)
+
+	for i := 0; i < 5; i++ {
+		tableName := fmt.Sprintf("items_%d", i)
+		assert.Equal(t, int64(10), resolvedData.Tables[tableName].Rows[9].Values.GetOrNil("item_id"))
+
+		var count int
+		err = db.QueryRow(fmt.Sprintf("select count(*) from %s", tableName)).Scan(&count)
+		assert.NilError(t, err)
+		assert.Equal(t, 10, count)
+	}
+}
+
+func TestResolveBatchFunc(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	data := testSQLData()
+	for i := 0; i < 600; i++ {
+		data.Add(tableSQLTags, debefix.MapValues{
+			"tag_id": i + 10,
+			"name":   fmt.Sprintf("tag %d", i),
+		})
+	}
+
+	resolvedData, err := debefix.Resolve(ctx, data, sqlite.ResolveFunc(db),
+		debefix.WithResolveOptionBatchCallback(sql.ResolveBatchFunc(db, sqlite.Dialect())))
+	assert.NilError(t, err)
+	assert.Equal(t, 601, len(resolvedData.Tables[tableSQLTags.TableID()].Rows))
+
+	var count int
+	err = db.QueryRow("select count(*) from tags").Scan(&count)
+	assert.NilError(t, err)
+	assert.Equal(t, 601, count)
+}
@@ -0,0 +1,200 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rrgmc/debefix/v2"
+)
+
+// Introspector reads the schema of the tables of a database.
+type Introspector interface {
+	Introspect(ctx context.Context, qi QueryInterface) ([]TableInfo, error)
+}
+
+// TableInfo is the schema of a database table, with its foreign keys.
+type TableInfo struct {
+	TableID     debefix.TableID
+	Schema      *debefix.TableSchema
+	ForeignKeys []ForeignKey
+}
+
+// ForeignKey is a foreign key from the columns of a table to the columns of another table.
+type ForeignKey struct {
+	Columns    []string
+	RefTableID debefix.TableID
+	RefColumns []string
+}
+
+// LoadSchema reads the database schema using the introspector, and sets the schema of each table using
+// [debefix.Data.SetTableSchema], and its dependencies on the tables referenced by its foreign keys using
+// [debefix.Data.AddBreakableDependencies], so circular foreign keys don't prevent resolving. Foreign keys to tables
+// which were not introspected are ignored.
+func LoadSchema(ctx context.Context, qi QueryInterface, introspector Introspector, data *debefix.Data) error {
+	tables, err := introspector.Introspect(ctx, qi)
+	if err != nil {
+		return err
+	}
+	introspected := map[string]bool{}
+	for _, table := range tables {
+		introspected[table.TableID.TableID()] = true
+	}
+	for _, table := range tables {
+		data.SetTableSchema(table.TableID, table.Schema)
+		var deps []debefix.TableID
+		for _, fk := range table.ForeignKeys {
+			if introspected[fk.RefTableID.TableID()] {
+				deps = append(deps, fk.RefTableID)
+			}
+		}
+		data.AddBreakableDependencies(table.TableID, deps...)
+	}
+	return nil
+}
+
+// QueryIntrospector is an [Introspector] which reads the schema using SQL queries, like queries on the
+// information_schema tables. All queries receive Args as arguments, and must return their rows sorted.
+type QueryIntrospector struct {
+	// Columns returns the table name, column name, column type, and integers which are 1 if the column is nullable,
+	// has a default value, is an identity column and is a generated column, sorted by table and column position.
+	Columns string
+	// PrimaryKeys returns the table name and column name of the primary key columns, sorted by table and column
+	// position.
+	PrimaryKeys string
+	// ForeignKeys returns the table name, constraint name, column name, referenced table name and referenced column
+	// name of the foreign key columns, sorted by table, constraint and column position.
+	ForeignKeys string
+	// Args are the arguments of the queries.
+	Args []any
+}
+
+var _ Introspector = QueryIntrospector{}
+
+func (q QueryIntrospector) Introspect(ctx context.Context, qi QueryInterface) ([]TableInfo, error) {
+	var ret []TableInfo
+	err := queryScan(ctx, qi, q.Columns, q.Args, func(scan func(...any) error) error {
+		var tableName, columnName, columnType string
+		var nullable, hasDefault, identity, generated int
+		if err := scan(&tableName, &columnName, &columnType, &nullable, &hasDefault, &identity, &generated); err != nil {
+			return err
+		}
+		if len(ret) == 0 || ret[len(ret)-1].TableID.TableID() != tableName {
+			ret = append(ret, TableInfo{
+				TableID: debefix.TableName(tableName),
+				Schema:  &debefix.TableSchema{},
+			})
+		}
+		table := &ret[len(ret)-1]
+		table.Schema.Columns = append(table.Schema.Columns, debefix.ColumnSchema{
+			Name:       columnName,
+			Type:       ColumnGoType(columnType),
+			Nullable:   nullable == 1,
+			HasDefault: hasDefault == 1,
+			Identity:   identity == 1,
+			Generated:  generated == 1,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	tables := map[string]*TableInfo{}
+	for idx := range ret {
+		tables[ret[idx].TableID.TableID()] = &ret[idx]
+	}
+
+	err = queryScan(ctx, qi, q.PrimaryKeys, q.Args, func(scan func(...any) error) error {
+		var tableName, columnName string
+		if err := scan(&tableName, &columnName); err != nil {
+			return err
+		}
+		table, ok := tables[tableName]
+		if !ok {
+			return nil
+		}
+		for idx := range table.Schema.Columns {
+			if table.Schema.Columns[idx].Name == columnName {
+				table.Schema.Columns[idx].PrimaryKey = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lastConstraint := ""
+	err = queryScan(ctx, qi, q.ForeignKeys, q.Args, func(scan func(...any) error) error {
+		var tableName, constraintName, columnName, refTableName, refColumnName string
+		if err := scan(&tableName, &constraintName, &columnName, &refTableName, &refColumnName); err != nil {
+			return err
+		}
+		table, ok := tables[tableName]
+		if !ok {
+			return nil
+		}
+		constraint := tableName + "\x00" + constraintName
+		if constraint != lastConstraint {
+			table.ForeignKeys = append(table.ForeignKeys, ForeignKey{RefTableID: debefix.TableName(refTableName)})
+			lastConstraint = constraint
+		}
+		fk := &table.ForeignKeys[len(table.ForeignKeys)-1]
+		fk.Columns = append(fk.Columns, columnName)
+		fk.RefColumns = append(fk.RefColumns, refColumnName)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// queryScan executes a query and calls the callback for each returned row.
+func queryScan(ctx context.Context, qi QueryInterface, query string, args []any,
+	f func(scan func(...any) error) error) error {
+	rows, err := qi.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error executing query '%s': %w", query, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := f(rows.Scan); err != nil {
+			return fmt.Errorf("error reading rows of query '%s': %w", query, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error executing query '%s': %w", query, err)
+	}
+	return rows.Close()
+}
+
+// ColumnGoType returns the Go type of the values of a database column type, or nil if it is not known.
+// The type is found by the type name, like "varchar(100)" or "BIGINT", in the same way as SQLite type affinity.
+// UUID, JSON, date and time columns return nil, as their values can be strings or specific types, depending on the
+// database driver and the fixture file format.
+func ColumnGoType(columnType string) reflect.Type {
+	name := strings.ToLower(columnType)
+	switch {
+	case strings.Contains(name, "bool"):
+		return reflect.TypeFor[bool]()
+	case strings.Contains(name, "interval"), strings.Contains(name, "point"), strings.Contains(name, "uuid"),
+		strings.Contains(name, "json"), strings.Contains(name, "date"), strings.Contains(name, "time"):
+		return nil
+	case strings.Contains(name, "int"), strings.Contains(name, "serial"):
+		return reflect.TypeFor[int64]()
+	case strings.Contains(name, "char"), strings.Contains(name, "text"), strings.Contains(name, "clob"),
+		strings.Contains(name, "enum"):
+		return reflect.TypeFor[string]()
+	case strings.Contains(name, "real"), strings.Contains(name, "floa"), strings.Contains(name, "doub"),
+		strings.Contains(name, "numeric"), strings.Contains(name, "decimal"):
+		return reflect.TypeFor[float64]()
+	case strings.Contains(name, "blob"), strings.Contains(name, "bytea"), strings.Contains(name, "binary"):
+		return reflect.TypeFor[[]byte]()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,162 @@
+package sql_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rrgmc/debefix/v2"
+	"github.com/rrgmc/debefix/v2/sql"
+	"github.com/rrgmc/debefix/v2/sql/sqlite"
+	"gotest.tools/v3/assert"
+)
+
+func TestLoadSchema(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	_, err := db.Exec(`
+create table post_tags (
+	post_id text not null references posts,
+	tag_id integer not null,
+	tag_name text not null,
+	upper_tag_name text generated always as (upper(tag_name)),
+	primary key (post_id, tag_id),
+	foreign key (tag_id) references tags (tag_id)
+);
+`)
+	assert.NilError(t, err)
+
+	tables, err := sqlite.Introspector().Introspect(ctx, db)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, []sql.TableInfo{
+		{
+			TableID: debefix.TableName("post_tags"),
+			Schema: &debefix.TableSchema{Columns: []debefix.ColumnSchema{
+				{Name: "post_id", Type: reflect.TypeFor[string](), PrimaryKey: true},
+				{Name: "tag_id", Type: reflect.TypeFor[int64](), PrimaryKey: true},
+				{Name: "tag_name", Type: reflect.TypeFor[string]()},
+				{Name: "upper_tag_name", Type: reflect.TypeFor[string](), Nullable: true, Generated: true},
+			}},
+			ForeignKeys: []sql.ForeignKey{
+				{Columns: []string{"tag_id"}, RefTableID: debefix.TableName("tags"), RefColumns: []string{"tag_id"}},
+				{Columns: []string{"post_id"}, RefTableID: debefix.TableName("posts"), RefColumns: []string{"post_id"}},
+			},
+		},
+		{
+			TableID: debefix.TableName("posts"),
+			Schema: &debefix.TableSchema{Columns: []debefix.ColumnSchema{
+				{Name: "post_id", Type: reflect.TypeFor[string](), HasDefault: true, PrimaryKey: true},
+				{Name: "tag_id", Type: reflect.TypeFor[int64]()},
+				{Name: "title", Type: reflect.TypeFor[string]()},
+			}},
+			ForeignKeys: []sql.ForeignKey{
+				{Columns: []string{"tag_id"}, RefTableID: debefix.TableName("tags"), RefColumns: []string{"tag_id"}},
+			},
+		},
+		{
+			TableID: debefix.TableName("tags"),
+			Schema: &debefix.TableSchema{Columns: []debefix.ColumnSchema{
+				{Name: "tag_id", Type: reflect.TypeFor[int64](), PrimaryKey: true, Identity: true},
+				{Name: "name", Type: reflect.TypeFor[string]()},
+			}},
+		},
+	}, tables, cmp.Comparer(func(a, b reflect.Type) bool {
+		return a == b
+	}))
+
+	data := debefix.NewData()
+	err = sql.LoadSchema(ctx, db, sqlite.Introspector(), data)
+	assert.NilError(t, err)
+
+	data.AddValues(debefix.TableName("post_tags"), debefix.MapValues{
+		"post_id":  debefix.ValueRefID(tableSQLPosts, "post_1", "post_id"),
+		"tag_id":   debefix.ValueRefID(tableSQLTags, "go", "tag_id"),
+		"tag_name": "Go",
+	})
+	data.AddValues(tableSQLTags, debefix.MapValues{
+		"_refid": debefix.SetValueRefID("go"),
+		"name":   "Go",
+	})
+	data.AddValues(tableSQLPosts, debefix.MapValues{
+		"_refid":  debefix.SetValueRefID("post_1"),
+		"post_id": debefix.ResolveValueUUID(),
+		"tag_id":  debefix.ValueRefID(tableSQLTags, "go", "tag_id"),
+		"title":   "First post",
+	})
+	assert.NilError(t, data.Err())
+	assert.DeepEqual(t, []debefix.TableID{debefix.TableName("tags"), debefix.TableName("posts")},
+		data.Tables["post_tags"].BreakableDepends)
+
+	// the tags identity column is resolved from the database.
+	_, err = debefix.Resolve(ctx, data, sqlite.ResolveFunc(db))
+	assert.NilError(t, err)
+
+	var tagName, upperTagName string
+	err = db.QueryRow(`select tag_name, upper_tag_name from post_tags`).Scan(&tagName, &upperTagName)
+	assert.NilError(t, err)
+	assert.Equal(t, "GO", upperTagName)
+}
+
+func TestLoadSchemaCycle(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	_, err := db.Exec(`
+create table departments (
+	department_id integer primary key,
+	head_user_id integer references users (user_id)
+);
+create table users (
+	user_id integer primary key,
+	department_id integer references departments (department_id)
+);
+create table events (
+	event_id integer primary key,
+	name text not null
+);
+`)
+	assert.NilError(t, err)
+
+	tableDepartments := debefix.TableName("departments")
+	tableUsers := debefix.TableName("users")
+	tableEvents := debefix.TableName("events")
+
+	// rows only in a table outside the cycle.
+	data := debefix.NewData()
+	err = sql.LoadSchema(ctx, db, sqlite.Introspector(), data)
+	assert.NilError(t, err)
+	data.Add(tableEvents, debefix.MapValues{"event_id": 1, "name": "launch"})
+
+	_, err = debefix.Resolve(ctx, data, sqlite.ResolveFunc(db))
+	assert.NilError(t, err)
+
+	// rows in the cycle, referencing each other.
+	data = debefix.NewData()
+	err = sql.LoadSchema(ctx, db, sqlite.Introspector(), data)
+	assert.NilError(t, err)
+	data.Add(tableDepartments, debefix.MapValues{
+		"_refid":        debefix.SetValueRefID("sales"),
+		"department_id": 1,
+		"head_user_id":  debefix.ValueRefID(tableUsers, "mary", "user_id"),
+	})
+	data.Add(tableUsers, debefix.MapValues{
+		"_refid":        debefix.SetValueRefID("mary"),
+		"user_id":       10,
+		"department_id": debefix.ValueRefID(tableDepartments, "sales", "department_id"),
+	})
+	assert.NilError(t, data.Err())
+
+	_, err = debefix.Resolve(ctx, data, sqlite.ResolveFunc(db))
+	assert.NilError(t, err)
+
+	var headUserID, departmentID int
+	err = db.QueryRow(`select head_user_id from departments where department_id = 1`).Scan(&headUserID)
+	assert.NilError(t, err)
+	assert.Equal(t, 10, headUserID)
+	err = db.QueryRow(`select department_id from users where user_id = 10`).Scan(&departmentID)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, departmentID)
+}
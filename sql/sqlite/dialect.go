@@ -0,0 +1,37 @@
+// Package sqlite implements the SQLite SQL dialect.
+package sqlite
+
+import (
+	"strings"
+
+	"github.com/rrgmc/debefix/v2"
+	"github.com/rrgmc/debefix/v2/sql"
+)
+
+// Dialect returns the SQLite SQL dialect. The "RETURNING" clause requires SQLite 3.35 or later.
+func Dialect() sql.Dialect {
+	return dialect{}
+}
+
+// ResolveFunc returns a [debefix.ResolveCallback] which executes SQLite statements using [sql.ResolveFunc].
+func ResolveFunc(qi sql.QueryInterface) debefix.ResolveCallback {
+	return sql.ResolveFunc(qi, Dialect())
+}
+
+type dialect struct{}
+
+func (d dialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (d dialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (d dialect) ReturningStyle() sql.ReturningStyle {
+	return sql.ReturningClause
+}
+
+func (d dialect) DefaultValues() string {
+	return "DEFAULT VALUES"
+}
@@ -0,0 +1,34 @@
+package sqlite
+
+import "github.com/rrgmc/debefix/v2/sql"
+
+// Introspector returns a [sql.Introspector] which reads the schema of all tables of a SQLite database, using the
+// pragma table functions. "INTEGER PRIMARY KEY" columns, which are aliases of the row id, are identity columns.
+func Introspector() sql.Introspector {
+	return sql.QueryIntrospector{
+		Columns: `
+select m.name, c.name, c.type,
+	case when c."notnull" = 0 and c.pk = 0 then 1 else 0 end,
+	case when c.dflt_value is not null then 1 else 0 end,
+	case when c.pk = 1 and upper(c.type) = 'INTEGER' and
+		(select count(*) from pragma_table_info(m.name) p where p.pk > 0) = 1 then 1 else 0 end,
+	case when c.hidden in (2, 3) then 1 else 0 end
+from sqlite_master m
+join pragma_table_xinfo(m.name) c
+where m.type = 'table' and m.name not like 'sqlite_%'
+order by m.name, c.cid`,
+		PrimaryKeys: `
+select m.name, c.name
+from sqlite_master m
+join pragma_table_info(m.name) c
+where m.type = 'table' and m.name not like 'sqlite_%' and c.pk > 0
+order by m.name, c.pk`,
+		ForeignKeys: `
+select m.name, cast(f.id as text), f."from", f."table",
+	coalesce(f."to", (select p.name from pragma_table_info(f."table") p where p.pk = f.seq + 1))
+from sqlite_master m
+join pragma_foreign_key_list(m.name) f
+where m.type = 'table' and m.name not like 'sqlite_%'
+order by m.name, f.id, f.seq`,
+	}
+}
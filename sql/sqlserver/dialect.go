@@ -0,0 +1,38 @@
+// Package sqlserver implements the Microsoft SQL Server SQL dialect.
+package sqlserver
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rrgmc/debefix/v2"
+	"github.com/rrgmc/debefix/v2/sql"
+)
+
+// Dialect returns the Microsoft SQL Server SQL dialect.
+func Dialect() sql.Dialect {
+	return dialect{}
+}
+
+// ResolveFunc returns a [debefix.ResolveCallback] which executes SQL Server statements using [sql.ResolveFunc].
+func ResolveFunc(qi sql.QueryInterface) debefix.ResolveCallback {
+	return sql.ResolveFunc(qi, Dialect())
+}
+
+type dialect struct{}
+
+func (d dialect) QuoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+func (d dialect) Placeholder(index int) string {
+	return "@p" + strconv.Itoa(index)
+}
+
+func (d dialect) ReturningStyle() sql.ReturningStyle {
+	return sql.ReturningOutput
+}
+
+func (d dialect) DefaultValues() string {
+	return "DEFAULT VALUES"
+}
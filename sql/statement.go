@@ -0,0 +1,255 @@
+package sql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rrgmc/debefix/v2"
+)
+
+// Statement is a parameterized SQL statement.
+type Statement struct {
+	Query     string
+	Args      []any
+	Returning []string // fields whose values are generated by the database, in the order they are returned.
+}
+
+// BuildStatement builds the statement for a resolve callback call. Fields containing a [debefix.ResolveValue] are
+// not sent to the database, and are returned by the statement if the dialect supports it.
+// Fields are sorted by name, so the same values always generate the same statement.
+func BuildStatement(dialect Dialect, resolveInfo debefix.ResolveInfo, values debefix.Values) (Statement, error) {
+	switch resolveInfo.Type {
+	case debefix.ResolveTypeAdd:
+		return BuildInsert(dialect, resolveInfo.TableID, values)
+	case debefix.ResolveTypeUpdate:
+		return BuildUpdate(dialect, resolveInfo.TableID, resolveInfo.UpdateKeyFields, values)
+	case debefix.ResolveTypeDelete:
+		return BuildDelete(dialect, resolveInfo.TableID, resolveInfo.UpdateKeyFields, values)
+	default:
+		return Statement{}, fmt.Errorf("unknown resolve type %d", resolveInfo.Type)
+	}
+}
+
+// BuildInsert builds an INSERT statement.
+func BuildInsert(dialect Dialect, tableID debefix.TableID, values debefix.Values) (Statement, error) {
+	fields, returning := splitFields(values)
+
+	var ret Statement
+	var b strings.Builder
+	b.WriteString("INSERT INTO ")
+	b.WriteString(QuoteName(dialect, tableID.TableName()))
+
+	if len(fields) == 0 {
+		b.WriteString(returningOutput(dialect, returning))
+		b.WriteString(" ")
+		b.WriteString(dialect.DefaultValues())
+	} else {
+		var placeholders []string
+		for _, field := range fields {
+			ret.Args = append(ret.Args, values.GetOrNil(field))
+			placeholders = append(placeholders, dialect.Placeholder(len(ret.Args)))
+		}
+		b.WriteString(" (")
+		b.WriteString(quoteFields(dialect, fields))
+		b.WriteString(")")
+		b.WriteString(returningOutput(dialect, returning))
+		b.WriteString(" VALUES (")
+		b.WriteString(strings.Join(placeholders, ", "))
+		b.WriteString(")")
+	}
+	b.WriteString(returningClause(dialect, returning))
+
+	ret.Query = b.String()
+	ret.Returning = returning
+	return ret, nil
+}
+
+// BuildInsertBatch builds multi-row INSERT statements. Consecutive rows with the same fields are inserted in the
+// same statement, with at most maxArgs arguments per statement (0 means no limit).
+// Values with [debefix.ResolveValue] fields cannot be inserted in batches, and cause an error.
+func BuildInsertBatch[V debefix.Values](dialect Dialect, tableID debefix.TableID, values []V, maxArgs int) ([]Statement, error) {
+	var ret []Statement
+	var current Statement
+	var currentFields []string
+	var b strings.Builder
+
+	flush := func() {
+		if current.Query != "" {
+			ret = append(ret, current)
+		}
+		current = Statement{}
+		currentFields = nil
+	}
+
+	for idx, rowValues := range values {
+		fields, returning := splitFields(rowValues)
+		if len(returning) > 0 {
+			return nil, fmt.Errorf("row %d of table '%s' has fields to be returned, cannot insert in batch",
+				idx, tableID.TableID())
+		}
+		if len(fields) == 0 {
+			flush()
+			stmt, err := BuildInsert(dialect, tableID, rowValues)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, stmt)
+			continue
+		}
+		if !slices.Equal(fields, currentFields) || (maxArgs > 0 && len(current.Args)+len(fields) > maxArgs) {
+			flush()
+		}
+
+		b.Reset()
+		if current.Query == "" {
+			currentFields = fields
+			b.WriteString("INSERT INTO ")
+			b.WriteString(QuoteName(dialect, tableID.TableName()))
+			b.WriteString(" (")
+			b.WriteString(quoteFields(dialect, fields))
+			b.WriteString(") VALUES ")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		for fieldIdx, field := range fields {
+			if fieldIdx > 0 {
+				b.WriteString(", ")
+			}
+			current.Args = append(current.Args, rowValues.GetOrNil(field))
+			b.WriteString(dialect.Placeholder(len(current.Args)))
+		}
+		b.WriteString(")")
+		current.Query += b.String()
+	}
+	flush()
+
+	return ret, nil
+}
+
+// BuildUpdate builds an UPDATE statement, using keyFields in the WHERE clause and setting all other fields.
+func BuildUpdate(dialect Dialect, tableID debefix.TableID, keyFields []string, values debefix.Values) (Statement, error) {
+	if len(keyFields) == 0 {
+		return Statement{}, fmt.Errorf("no key fields to update table '%s'", tableID.TableID())
+	}
+
+	fields, returning := splitFields(values)
+	fields = slices.DeleteFunc(fields, func(field string) bool {
+		return slices.Contains(keyFields, field)
+	})
+	if len(fields) == 0 {
+		return Statement{}, fmt.Errorf("no fields to update in table '%s'", tableID.TableID())
+	}
+
+	var ret Statement
+	var b strings.Builder
+	b.WriteString("UPDATE ")
+	b.WriteString(QuoteName(dialect, tableID.TableName()))
+	b.WriteString(" SET ")
+	for idx, field := range fields {
+		if idx > 0 {
+			b.WriteString(", ")
+		}
+		ret.Args = append(ret.Args, values.GetOrNil(field))
+		b.WriteString(dialect.QuoteIdentifier(field))
+		b.WriteString(" = ")
+		b.WriteString(dialect.Placeholder(len(ret.Args)))
+	}
+	b.WriteString(returningOutput(dialect, returning))
+	where, err := buildWhere(dialect, tableID, keyFields, values, &ret)
+	if err != nil {
+		return Statement{}, err
+	}
+	b.WriteString(where)
+	b.WriteString(returningClause(dialect, returning))
+
+	ret.Query = b.String()
+	ret.Returning = returning
+	return ret, nil
+}
+
+// BuildDelete builds a DELETE statement, using keyFields in the WHERE clause.
+func BuildDelete(dialect Dialect, tableID debefix.TableID, keyFields []string, values debefix.Values) (Statement, error) {
+	if len(keyFields) == 0 {
+		return Statement{}, fmt.Errorf("no key fields to delete from table '%s'", tableID.TableID())
+	}
+
+	var ret Statement
+	var b strings.Builder
+	b.WriteString("DELETE FROM ")
+	b.WriteString(QuoteName(dialect, tableID.TableName()))
+	where, err := buildWhere(dialect, tableID, keyFields, values, &ret)
+	if err != nil {
+		return Statement{}, err
+	}
+	b.WriteString(where)
+
+	ret.Query = b.String()
+	return ret, nil
+}
+
+// buildWhere builds a WHERE clause comparing the key fields, appending their values to the statement arguments.
+func buildWhere(dialect Dialect, tableID debefix.TableID, keyFields []string, values debefix.Values,
+	stmt *Statement) (string, error) {
+	var b strings.Builder
+	b.WriteString(" WHERE ")
+	for idx, keyField := range keyFields {
+		keyValue, ok := values.Get(keyField)
+		if !ok {
+			return "", fmt.Errorf("key field '%s' not found in table '%s' values", keyField, tableID.TableID())
+		}
+		if _, isResolve := keyValue.(debefix.ResolveValue); isResolve {
+			return "", fmt.Errorf("key field '%s' of table '%s' was not resolved", keyField, tableID.TableID())
+		}
+		if idx > 0 {
+			b.WriteString(" AND ")
+		}
+		stmt.Args = append(stmt.Args, keyValue)
+		b.WriteString(dialect.QuoteIdentifier(keyField))
+		b.WriteString(" = ")
+		b.WriteString(dialect.Placeholder(len(stmt.Args)))
+	}
+	return b.String(), nil
+}
+
+// splitFields returns the sorted field names with values to be sent to the database, and the ones with values to be
+// returned by it.
+func splitFields(values debefix.Values) (fields []string, returning []string) {
+	for fieldName, fieldValue := range values.All {
+		if _, ok := fieldValue.(debefix.ResolveValue); ok {
+			returning = append(returning, fieldName)
+		} else {
+			fields = append(fields, fieldName)
+		}
+	}
+	slices.Sort(fields)
+	slices.Sort(returning)
+	return
+}
+
+func quoteFields(dialect Dialect, fields []string) string {
+	quoted := make([]string, len(fields))
+	for idx, field := range fields {
+		quoted[idx] = dialect.QuoteIdentifier(field)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func returningOutput(dialect Dialect, returning []string) string {
+	if len(returning) == 0 || dialect.ReturningStyle() != ReturningOutput {
+		return ""
+	}
+	quoted := make([]string, len(returning))
+	for idx, field := range returning {
+		quoted[idx] = "INSERTED." + dialect.QuoteIdentifier(field)
+	}
+	return " OUTPUT " + strings.Join(quoted, ", ")
+}
+
+func returningClause(dialect Dialect, returning []string) string {
+	if len(returning) == 0 || dialect.ReturningStyle() != ReturningClause {
+		return ""
+	}
+	return " RETURNING " + quoteFields(dialect, returning)
+}
@@ -0,0 +1,151 @@
+package sql_test
+
+import (
+	"testing"
+
+	"github.com/rrgmc/debefix/v2"
+	"github.com/rrgmc/debefix/v2/sql"
+	"github.com/rrgmc/debefix/v2/sql/mysql"
+	"github.com/rrgmc/debefix/v2/sql/postgres"
+	"github.com/rrgmc/debefix/v2/sql/sqlite"
+	"github.com/rrgmc/debefix/v2/sql/sqlserver"
+	"gotest.tools/v3/assert"
+)
+
+var tableTags = debefix.TableName("public.tags")
+
+func TestBuildStatement(t *testing.T) {
+	values := debefix.MapValues{
+		"tag_id":     debefix.ResolveValueResolve(),
+		"name":       "Go",
+		"created_at": "2024-01-01",
+		"updated_at": nil,
+	}
+	updateValues := debefix.MapValues{
+		"tag_id":     1,
+		"name":       "Go",
+		"updated_at": debefix.ResolveValueResolve(),
+	}
+
+	tests := []struct {
+		name           string
+		dialect        sql.Dialect
+		expectedInsert string
+		expectedUpdate string
+	}{
+		{
+			name:           "postgres",
+			dialect:        postgres.Dialect(),
+			expectedInsert: `INSERT INTO "public"."tags" ("created_at", "name", "updated_at") VALUES ($1, $2, $3) RETURNING "tag_id"`,
+			expectedUpdate: `UPDATE "public"."tags" SET "name" = $1 WHERE "tag_id" = $2 RETURNING "updated_at"`,
+		},
+		{
+			name:           "sqlite",
+			dialect:        sqlite.Dialect(),
+			expectedInsert: `INSERT INTO "public"."tags" ("created_at", "name", "updated_at") VALUES (?, ?, ?) RETURNING "tag_id"`,
+			expectedUpdate: `UPDATE "public"."tags" SET "name" = ? WHERE "tag_id" = ? RETURNING "updated_at"`,
+		},
+		{
+			name:           "mysql",
+			dialect:        mysql.Dialect(),
+			expectedInsert: "INSERT INTO `public`.`tags` (`created_at`, `name`, `updated_at`) VALUES (?, ?, ?)",
+			expectedUpdate: "UPDATE `public`.`tags` SET `name` = ? WHERE `tag_id` = ?",
+		},
+		{
+			name:           "sqlserver",
+			dialect:        sqlserver.Dialect(),
+			expectedInsert: `INSERT INTO [public].[tags] ([created_at], [name], [updated_at]) OUTPUT INSERTED.[tag_id] VALUES (@p1, @p2, @p3)`,
+			expectedUpdate: `UPDATE [public].[tags] SET [name] = @p1 OUTPUT INSERTED.[updated_at] WHERE [tag_id] = @p2`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmt, err := sql.BuildStatement(test.dialect, debefix.ResolveInfo{
+				Type:    debefix.ResolveTypeAdd,
+				TableID: tableTags,
+			}, values)
+			assert.NilError(t, err)
+			assert.Equal(t, test.expectedInsert, stmt.Query)
+			assert.DeepEqual(t, []any{"2024-01-01", "Go", nil}, stmt.Args)
+			assert.DeepEqual(t, []string{"tag_id"}, stmt.Returning)
+
+			stmt, err = sql.BuildStatement(test.dialect, debefix.ResolveInfo{
+				Type:            debefix.ResolveTypeUpdate,
+				TableID:         tableTags,
+				UpdateKeyFields: []string{"tag_id"},
+			}, updateValues)
+			assert.NilError(t, err)
+			assert.Equal(t, test.expectedUpdate, stmt.Query)
+			assert.DeepEqual(t, []any{"Go", 1}, stmt.Args)
+			assert.DeepEqual(t, []string{"updated_at"}, stmt.Returning)
+		})
+	}
+}
+
+func TestBuildInsertDefaultValues(t *testing.T) {
+	stmt, err := sql.BuildInsert(postgres.Dialect(), tableTags, debefix.MapValues{
+		"tag_id": debefix.ResolveValueResolve(),
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, `INSERT INTO "public"."tags" DEFAULT VALUES RETURNING "tag_id"`, stmt.Query)
+
+	stmt, err = sql.BuildInsert(mysql.Dialect(), tableTags, debefix.MapValues{})
+	assert.NilError(t, err)
+	assert.Equal(t, "INSERT INTO `public`.`tags` () VALUES ()", stmt.Query)
+}
+
+func TestBuildUpdateErrors(t *testing.T) {
+	_, err := sql.BuildUpdate(postgres.Dialect(), tableTags, nil, debefix.MapValues{"name": "Go"})
+	assert.ErrorContains(t, err, "no key fields")
+
+	_, err = sql.BuildUpdate(postgres.Dialect(), tableTags, []string{"tag_id"}, debefix.MapValues{"name": "Go"})
+	assert.ErrorContains(t, err, "key field 'tag_id' not found")
+}
+
+func TestBuildDelete(t *testing.T) {
+	stmt, err := sql.BuildStatement(sqlserver.Dialect(), debefix.ResolveInfo{
+		Type:            debefix.ResolveTypeDelete,
+		TableID:         tableTags,
+		UpdateKeyFields: []string{"tag_id", "name"},
+	}, debefix.MapValues{"tag_id": 1, "name": "Go", "created_at": "2024-01-01"})
+	assert.NilError(t, err)
+	assert.Equal(t, `DELETE FROM [public].[tags] WHERE [tag_id] = @p1 AND [name] = @p2`, stmt.Query)
+	assert.DeepEqual(t, []any{1, "Go"}, stmt.Args)
+
+	_, err = sql.BuildDelete(postgres.Dialect(), tableTags, nil, debefix.MapValues{"tag_id": 1})
+	assert.ErrorContains(t, err, "no key fields")
+}
+
+func TestBuildInsertBatch(t *testing.T) {
+	stmts, err := sql.BuildInsertBatch(postgres.Dialect(), tableTags, []debefix.MapValues{
+		{"tag_id": 1, "name": "Go"},
+		{"tag_id": 2, "name": "C++"},
+		{"tag_id": 3, "name": "Java"},
+		{"tag_id": 4},
+		{},
+	}, 4)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []sql.Statement{
+		{
+			Query: `INSERT INTO "public"."tags" ("name", "tag_id") VALUES ($1, $2), ($3, $4)`,
+			Args:  []any{"Go", 1, "C++", 2},
+		},
+		{
+			Query: `INSERT INTO "public"."tags" ("name", "tag_id") VALUES ($1, $2)`,
+			Args:  []any{"Java", 3},
+		},
+		{
+			Query: `INSERT INTO "public"."tags" ("tag_id") VALUES ($1)`,
+			Args:  []any{4},
+		},
+		{
+			Query: `INSERT INTO "public"."tags" DEFAULT VALUES`,
+		},
+	}, stmts)
+
+	_, err = sql.BuildInsertBatch(postgres.Dialect(), tableTags, []debefix.MapValues{
+		{"tag_id": debefix.ResolveValueResolve()},
+	}, 0)
+	assert.ErrorContains(t, err, "cannot insert in batch")
+}
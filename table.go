@@ -1,21 +1,140 @@
 package debefix
 
-import "slices"
+import (
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
 
 // Table represents a table, its dependencies, and list of rows.
 type Table struct {
-	TableID TableID
-	Depends []TableID
-	Rows    []*Row
+	TableID          TableID
+	Depends          []TableID
+	BreakableDepends []TableID    // dependencies which are ignored when needed to break circular dependencies.
+	KeyFields        []string     // fields which uniquely identify a row, used to update rows.
+	Tags             []string     // tags of all rows of the table, used to filter rows when resolving.
+	Schema           *TableSchema // schema of the table columns, if set.
+	Rows             []*Row
+
+	index   *rowIndex
+	indexMu sync.Mutex // protects index, as tables can be read concurrently while resolving.
 }
 
 // AddDependencies adds dependencies on another tables.
 func (t *Table) AddDependencies(deps ...TableID) {
+	t.Depends = t.appendDependencies(t.Depends, deps)
+}
+
+// AddBreakableDependencies adds dependencies on another tables, which are respected when ordering the tables, but
+// are ignored if they are part of a circular dependency.
+func (t *Table) AddBreakableDependencies(deps ...TableID) {
+	t.BreakableDepends = t.appendDependencies(t.BreakableDepends, deps)
+}
+
+// appendDependencies appends the dependencies which are not the table itself and are not in the list yet.
+func (t *Table) appendDependencies(list []TableID, deps []TableID) []TableID {
 	for _, dep := range deps {
-		if dep.TableID() != t.TableID.TableID() && !slices.ContainsFunc(t.Depends, func(name TableID) bool {
+		if dep.TableID() != t.TableID.TableID() && !slices.ContainsFunc(list, func(name TableID) bool {
 			return dep.TableID() == name.TableID()
 		}) {
-			t.Depends = append(t.Depends, dep)
+			list = append(list, dep)
+		}
+	}
+	return list
+}
+
+// emptyCopy returns a new table with the same configuration, without dependencies and rows. All table copies are
+// created using it, so they keep every configuration field.
+func (t *Table) emptyCopy() *Table {
+	return &Table{
+		TableID:   t.TableID,
+		KeyFields: t.KeyFields,
+		Tags:      t.Tags,
+		Schema:    t.Schema,
+	}
+}
+
+// FindRefIDRow returns the first row with the RefID, or nil if not found.
+// Rows are looked up using an index, which is updated when rows are appended to Rows, and rebuilt when the Rows
+// slice is replaced or when the lookup finds a different row, in case rows were replaced in place.
+func (t *Table) FindRefIDRow(refID RefID) *Row {
+	return t.findRow(func(ix *rowIndex) (int, bool) {
+		pos, ok := ix.refIDs[refID]
+		return pos, ok
+	}, func(row *Row) bool {
+		return row.RefID == refID
+	})
+}
+
+// FindInternalIDRow returns the row with the internal id, or nil if not found.
+// Rows are looked up using an index, which is updated when rows are appended to Rows, and rebuilt when the Rows
+// slice is replaced or when the lookup finds a different row, in case rows were replaced in place.
+func (t *Table) FindInternalIDRow(internalID uuid.UUID) *Row {
+	return t.findRow(func(ix *rowIndex) (int, bool) {
+		pos, ok := ix.internalIDs[internalID]
+		return pos, ok
+	}, func(row *Row) bool {
+		return row.InternalID == internalID
+	})
+}
+
+// findRow looks up a row position in the index, checking that the row at the position matches. The index is
+// rebuilt and the lookup retried if the row don't match. Misses are trusted unless Rows changed since the index was
+// updated, so looking up rows which don't exist don't rebuild the index every time.
+func (t *Table) findRow(lookup func(ix *rowIndex) (int, bool), match func(row *Row) bool) *Row {
+	t.indexMu.Lock()
+	defer t.indexMu.Unlock()
+	if t.index == nil {
+		t.index = &rowIndex{}
+	}
+	ix := t.index
+
+	rebuilt := ix.update(t.Rows)
+	for {
+		pos, ok := lookup(ix)
+		if !ok {
+			return nil
+		}
+		if match(t.Rows[pos]) {
+			return t.Rows[pos]
+		}
+		if rebuilt {
+			return nil
+		}
+		// a row was replaced in place, rebuild the index.
+		ix.rows = nil
+		rebuilt = ix.update(t.Rows)
+	}
+}
+
+// rowIndex indexes the positions of the rows of a table by RefID and internal id.
+type rowIndex struct {
+	rows        []*Row // the indexed rows, to detect changes in Table.Rows.
+	refIDs      map[RefID]int
+	internalIDs map[uuid.UUID]int
+}
+
+// update indexes the rows which were appended since the last update, or all rows if the slice was replaced.
+// Returns whether all rows were indexed.
+func (ix *rowIndex) update(rows []*Row) bool {
+	start := len(ix.rows)
+	if start > len(rows) || (start > 0 && (&ix.rows[0] != &rows[0] || ix.rows[start-1] != rows[start-1])) {
+		start = 0
+	}
+	if start == 0 {
+		ix.refIDs = make(map[RefID]int, len(rows))
+		ix.internalIDs = make(map[uuid.UUID]int, len(rows))
+	}
+	for pos := start; pos < len(rows); pos++ {
+		row := rows[pos]
+		ix.internalIDs[row.InternalID] = pos
+		if row.RefID != "" {
+			if _, ok := ix.refIDs[row.RefID]; !ok {
+				ix.refIDs[row.RefID] = pos
+			}
 		}
 	}
+	ix.rows = rows
+	return start == 0
 }
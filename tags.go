@@ -0,0 +1,223 @@
+package debefix
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// TagFilter selects rows by their tags, which are the row tags and the tags of its table.
+type TagFilter interface {
+	MatchTags(tags []string) bool
+}
+
+// TagFilterFunc is a functional implementation of TagFilter.
+type TagFilterFunc func(tags []string) bool
+
+var _ TagFilter = TagFilterFunc(nil)
+
+func (f TagFilterFunc) MatchTags(tags []string) bool {
+	return f(tags)
+}
+
+// ParseTagFilter parses a tag expression into a TagFilter. Tags can be combined with "&" (and), "|" (or),
+// "!" (not) and parentheses, like "(dev | test) & !large". A single tag matches rows which contain it.
+func ParseTagFilter(expr string) (TagFilter, error) {
+	p := &tagFilterParser{expr: expr}
+	ret, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpaces(); p.pos < len(p.expr) {
+		return nil, p.errorf("unexpected '%c'", p.expr[p.pos])
+	}
+	return ret, nil
+}
+
+// tagFilterParser is a recursive descent parser of tag expressions.
+type tagFilterParser struct {
+	expr string
+	pos  int
+}
+
+func (p *tagFilterParser) parseOr() (TagFilter, error) {
+	var filters []TagFilter
+	for {
+		filter, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+		if !p.consume('|') {
+			break
+		}
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return TagFilterFunc(func(tags []string) bool {
+		return slices.ContainsFunc(filters, func(filter TagFilter) bool {
+			return filter.MatchTags(tags)
+		})
+	}), nil
+}
+
+func (p *tagFilterParser) parseAnd() (TagFilter, error) {
+	var filters []TagFilter
+	for {
+		filter, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+		if !p.consume('&') {
+			break
+		}
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return TagFilterFunc(func(tags []string) bool {
+		return !slices.ContainsFunc(filters, func(filter TagFilter) bool {
+			return !filter.MatchTags(tags)
+		})
+	}), nil
+}
+
+func (p *tagFilterParser) parseUnary() (TagFilter, error) {
+	switch {
+	case p.consume('!'):
+		filter, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return TagFilterFunc(func(tags []string) bool {
+			return !filter.MatchTags(tags)
+		}), nil
+	case p.consume('('):
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(')') {
+			return nil, p.errorf("expected ')'")
+		}
+		return filter, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.expr) && !strings.ContainsRune(" \t\n&|!()", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected tag")
+	}
+	tag := p.expr[start:p.pos]
+	return TagFilterFunc(func(tags []string) bool {
+		return slices.Contains(tags, tag)
+	}), nil
+}
+
+// consume skips spaces and consumes the character if it is the next one.
+func (p *tagFilterParser) consume(c byte) bool {
+	p.skipSpaces()
+	if p.pos < len(p.expr) && p.expr[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *tagFilterParser) skipSpaces() {
+	for p.pos < len(p.expr) && strings.ContainsRune(" \t\n", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *tagFilterParser) errorf(format string, args ...any) error {
+	return NewResolveErrorf("invalid tag expression '%s' at position %d: %s", p.expr, p.pos,
+		fmt.Sprintf(format, args...))
+}
+
+// filterTags returns a copy of data containing only the rows which match the tag filter.
+// Global updates targeting rows which don't match are removed. Rows which match but reference rows which don't,
+// through field values, or the targets and values of their updates, are returned as errors.
+func filterTags(data *Data, filter TagFilter) (*Data, error) {
+	s := newRowSelector(data)
+
+	tableIDs := slices.Sorted(maps.Keys(data.Tables))
+	for _, tableID := range tableIDs {
+		table := data.Tables[tableID]
+		s.selectedTables[tableID] = true
+		for _, row := range table.Rows {
+			if filter.MatchTags(append(slices.Clone(table.Tags), row.Tags...)) {
+				s.selected[row] = true
+			}
+		}
+	}
+
+	var errs []error
+	checkRef := func(source string, ref rowRef) {
+		if target := s.findRef(ref); target != nil && !s.selected[target] {
+			errs = append(errs, NewResolveErrorf("%s references row '%s' of table '%s' which was excluded by tags",
+				source, rowDescription(target), ref.tableID.TableID()))
+		}
+	}
+	checkValue := func(source string, fieldName string, value any) {
+		for _, ref := range valueRowRefs(value) {
+			checkRef(fmt.Sprintf("%s field '%s'", source, fieldName), ref)
+		}
+	}
+	checkUpdate := func(source string, update Update) {
+		if setValues, ok := update.Action.(UpdateActionSetValues); ok && setValues.Values != nil {
+			for _, fieldName := range sortedFieldNames(setValues.Values) {
+				checkValue(source, fieldName, setValues.Values.GetOrNil(fieldName))
+			}
+		}
+	}
+
+	for _, tableID := range tableIDs {
+		for _, row := range data.Tables[tableID].Rows {
+			if !s.selected[row] {
+				continue
+			}
+			source := fmt.Sprintf("row '%s' of table '%s'", rowDescription(row), tableID)
+			for _, fieldName := range sortedFieldNames(row.Values) {
+				checkValue(source, fieldName, row.Values.GetOrNil(fieldName))
+			}
+			for _, update := range row.Updates {
+				for _, ref := range updateQueryRowRefs(update.Query) {
+					checkRef(source+" update query", ref)
+				}
+				checkUpdate(source+" update", update)
+			}
+		}
+	}
+
+	var updates []Update
+	for updateIdx, update := range data.Updates {
+		if slices.ContainsFunc(updateQueryRowRefs(update.Query), func(ref rowRef) bool {
+			return s.isRefExcluded(ref)
+		}) {
+			continue
+		}
+		checkUpdate(fmt.Sprintf("update %d", updateIdx), update)
+		updates = append(updates, update)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return s.selectedData(updates), nil
+}
+
+// rowDescription returns the RefID of the row, or its internal id if not set.
+func rowDescription(row *Row) string {
+	if row.RefID != "" {
+		return string(row.RefID)
+	}
+	return row.InternalID.String()
+}
@@ -0,0 +1,97 @@
+package debefix
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseTagFilter(t *testing.T) {
+	tests := []struct {
+		expr     string
+		tags     []string
+		expected bool
+	}{
+		{"dev", []string{"dev"}, true},
+		{"dev", []string{"test"}, false},
+		{"!dev", []string{"test"}, true},
+		{"dev | test", []string{"test"}, true},
+		{"dev & test", []string{"test"}, false},
+		{"dev & test", []string{"test", "dev"}, true},
+		{"(dev | test) & !large", []string{"test"}, true},
+		{"(dev | test) & !large", []string{"test", "large"}, false},
+		{"dev | test & large", []string{"dev"}, true},
+	}
+
+	for _, test := range tests {
+		filter, err := ParseTagFilter(test.expr)
+		assert.NilError(t, err, test.expr)
+		assert.Equal(t, test.expected, filter.MatchTags(test.tags), "%s %v", test.expr, test.tags)
+	}
+
+	for _, expr := range []string{"", "dev &", "(dev", "dev)", "!"} {
+		_, err := ParseTagFilter(expr)
+		AssertIsResolveError(t, err)
+	}
+}
+
+func TestResolveTagFilter(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.SetTableTags(tableTags, "base")
+	data.Add(tableTags, MapValues{"_refid": SetValueRefID("go"), "tag_id": 1})
+	data.Add(tableTags, MapValues{"_refid": SetValueRefID("cpp"), "tag_id": 2}, WithDataAddTags("large"))
+	data.Add(tablePosts, MapValues{
+		"post_id": 1,
+		"tag_id":  ValueRefID(tableTags, "go", "tag_id"),
+	}, WithDataAddTags("dev"))
+	data.Add(tablePosts, MapValues{
+		"post_id": 2,
+		"tag_id":  ValueRefID(tableTags, "cpp", "tag_id"),
+	}, WithDataAddTags("dev", "large"))
+	data.Update(ValueRefID(tableTags, "cpp", "tag_id").UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"name": "C++"}})
+
+	filter, err := ParseTagFilter("(base | dev) & !large")
+	assert.NilError(t, err)
+
+	resolvedData, err := Resolve(ctx, data, ResolveCheckCallback, WithResolveOptionTagFilter(filter))
+	assert.NilError(t, err)
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{"tag_id": 1},
+	}, resolvedData.Tables[tableTags.TableID()].Rows)
+	AssertRowValuesDeepEqual(t, []map[string]any{
+		{"post_id": 1, "tag_id": 1},
+	}, resolvedData.Tables[tablePosts.TableID()].Rows)
+	assert.DeepEqual(t, []string{"base"}, resolvedData.Tables[tableTags.TableID()].Tags)
+	assert.DeepEqual(t, []string{"dev"}, resolvedData.Tables[tablePosts.TableID()].Rows[0].Tags)
+
+	// referenced rows which were excluded
+	filter, err = ParseTagFilter("dev | !large")
+	assert.NilError(t, err)
+	_, err = Resolve(ctx, data, ResolveCheckCallback, WithResolveOptionTagFilter(filter))
+	AssertIsResolveError(t, err)
+	assert.ErrorContains(t, err, "field 'tag_id' references row 'cpp' of table 'public.tags' which was excluded by tags")
+
+	// updates of rows targeting rows which were excluded
+	data = NewData()
+	data.Add(tableTags, MapValues{"_refid": SetValueRefID("go"), "tag_id": 1}, WithDataAddTags("prod"))
+	data.Add(tablePosts, MapValues{"_refid": SetValueRefID("post_1"), "post_id": 1}, WithDataAddTags("dev"))
+	data.UpdateAfter(ValueRefID(tablePosts, "post_1", "post_id"),
+		ValueRefID(tableTags, "go", "tag_id").UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"name": "Go"}})
+	assert.NilError(t, data.Err())
+
+	filter, err = ParseTagFilter("dev")
+	assert.NilError(t, err)
+	var calls int
+	_, err = Resolve(ctx, data, func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+		calls++
+		return nil
+	}, WithResolveOptionTagFilter(filter))
+	AssertIsResolveError(t, err)
+	assert.ErrorContains(t, err, "row 'post_1' of table 'public.posts' update query references row 'go' of table 'public.tags' which was excluded by tags")
+	assert.Equal(t, 0, calls)
+}
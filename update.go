@@ -22,6 +22,18 @@ type UpdateData struct {
 	Row       *Row
 }
 
+// keyFields returns the update key fields, or the table key fields if not set, which may come from its schema
+// primary key.
+func (u UpdateData) keyFields(data *Data) []string {
+	if len(u.KeyFields) > 0 {
+		return u.KeyFields
+	}
+	if table, ok := data.Tables[u.TableID.TableID()]; ok {
+		return table.KeyFields
+	}
+	return nil
+}
+
 // Update is an updated query and its corresponding action.
 type Update struct {
 	Query  UpdateQuery
@@ -0,0 +1,30 @@
+package debefix
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// uuidGenerator generates random UUIDs from a seeded source, so the same sequence is generated on every run.
+type uuidGenerator struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newUUIDGenerator(seed int64) *uuidGenerator {
+	return &uuidGenerator{
+		rnd: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// New returns a new random UUID. If the generator is nil, [uuid.New] is used.
+func (g *uuidGenerator) New() uuid.UUID {
+	if g == nil {
+		return uuid.New()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return uuid.Must(uuid.NewRandomFromReader(g.rnd))
+}
@@ -0,0 +1,123 @@
+package debefix
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Validate checks, without resolving any value, that the references of all field values and updates target existing
+// tables and rows, and that no row references itself. Values used as arguments of ValueFormat, ValueTemplate,
+// ValueDefault and ValueFormatFunc are also checked.
+// All problems are returned at once, as a joined error.
+func (d *Data) Validate() error {
+	return validateData(d, nil)
+}
+
+// validateData validates the data, allowing references to the rows of the base data.
+func validateData(data *Data, base *ResolvedData) error {
+	v := &dataValidator{data: data, base: base}
+
+	for _, tableID := range slices.Sorted(maps.Keys(data.Tables)) {
+		table := data.Tables[tableID]
+		for _, row := range table.Rows {
+			source := fmt.Sprintf("row '%s' of table '%s'", rowDescription(row), tableID)
+			if row.Origin != "" {
+				source += fmt.Sprintf(" (%s)", row.Origin)
+			}
+			for _, fieldName := range sortedFieldNames(row.Values) {
+				v.checkValue(source, fieldName, tableID, row, row.Values.GetOrNil(fieldName))
+			}
+			for _, update := range row.Updates {
+				v.checkUpdate(source+" update", update)
+			}
+		}
+	}
+
+	for updateIdx, update := range data.Updates {
+		v.checkUpdate(fmt.Sprintf("update %d", updateIdx), update)
+	}
+
+	return errors.Join(v.errs...)
+}
+
+type dataValidator struct {
+	data *Data
+	base *ResolvedData
+	errs []error
+}
+
+// checkValue checks the references of a field value of a row. The row is nil for update values.
+func (v *dataValidator) checkValue(source string, fieldName string, tableID string, row *Row, value any) {
+	for _, ref := range valueRowRefs(value) {
+		if row != nil && ref.tableID.TableID() == tableID &&
+			((ref.refID != "" && ref.refID == row.RefID) || (ref.refID == "" && ref.internalID == row.InternalID)) {
+			v.errs = append(v.errs, NewResolveErrorf("%s field '%s' references its own row", source, fieldName))
+			continue
+		}
+		if err := v.checkRef(ref); err != nil {
+			v.errs = append(v.errs, NewResolveErrorf("%s field '%s': %w", source, fieldName, err))
+		}
+	}
+	for _, dep := range valueTableDependencies(value) {
+		if !v.tableExists(dep.TableID()) {
+			v.errs = append(v.errs, NewResolveErrorf("%s field '%s': table '%s' not found", source, fieldName,
+				dep.TableID()))
+		}
+	}
+}
+
+// checkUpdate checks the rows targeted by an update query and the references of its values.
+func (v *dataValidator) checkUpdate(source string, update Update) {
+	for _, ref := range updateQueryRowRefs(update.Query) {
+		if err := v.checkRef(ref); err != nil {
+			v.errs = append(v.errs, NewResolveErrorf("%s query: %w", source, err))
+		}
+	}
+	if setValues, ok := update.Action.(UpdateActionSetValues); ok && setValues.Values != nil {
+		for _, fieldName := range sortedFieldNames(setValues.Values) {
+			v.checkValue(source, fieldName, "", nil, setValues.Values.GetOrNil(fieldName))
+		}
+	}
+}
+
+// checkRef returns an error if the referenced table or row don't exist.
+func (v *dataValidator) checkRef(ref rowRef) error {
+	tableID := ref.tableID.TableID()
+	if !v.tableExists(tableID) {
+		return fmt.Errorf("table '%s' not found", tableID)
+	}
+	if v.findRef(v.data.Tables[tableID], ref) {
+		return nil
+	}
+	if v.base != nil && v.findRef(v.base.Tables[tableID], ref) {
+		return nil
+	}
+	if ref.refID != "" {
+		return fmt.Errorf("refID '%s' not found in table '%s'", ref.refID, tableID)
+	}
+	return fmt.Errorf("internal ID %v not found in table '%s'", ref.internalID, tableID)
+}
+
+func (v *dataValidator) findRef(table *Table, ref rowRef) bool {
+	if table == nil {
+		return false
+	}
+	if ref.refID != "" {
+		return table.FindRefIDRow(ref.refID) != nil
+	}
+	return table.FindInternalIDRow(ref.internalID) != nil
+}
+
+func (v *dataValidator) tableExists(tableID string) bool {
+	if _, ok := v.data.Tables[tableID]; ok {
+		return true
+	}
+	if v.base != nil {
+		if _, ok := v.base.Tables[tableID]; ok {
+			return true
+		}
+	}
+	return false
+}
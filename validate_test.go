@@ -0,0 +1,86 @@
+package debefix
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestDataValidate(t *testing.T) {
+	data := NewData()
+	data.Add(tableTags, MapValues{
+		"_refid": SetValueRefID("go"),
+		"tag_id": 1,
+	})
+	data.Add(tablePosts, MapValues{
+		"_refid":  SetValueRefID("post_1"),
+		"post_id": 1,
+		"tag_id":  ValueRefID(tableTags, "go", "tag_id"),
+		"title":   ValueFormat("%s post", ValueRefID(tableTags, "javascript", "tag_name")),
+		"parent":  ValueDefault(ValueRefID(tablePosts, "post_1", "post_id"), nil),
+		"user_id": ValueTemplate("{{.user}}", map[string]any{"user": ValueRefID(TableName("users"), "johndoe", "user_id")}),
+	}, WithDataAddOrigin("posts.yaml:3:5"))
+	missingIID := uuid.New()
+	data.Update(NewInternalIDRef(tableTags, missingIID).UpdateQuery([]string{"tag_id"}),
+		UpdateActionSetValues{Values: MapValues{"tag_name": "Go"}})
+
+	err := data.Validate()
+	AssertIsResolveError(t, err)
+	assert.Error(t, err, `row 'post_1' of table 'public.posts' (posts.yaml:3:5) field 'parent' references its own row
+row 'post_1' of table 'public.posts' (posts.yaml:3:5) field 'title': refID 'javascript' not found in table 'public.tags'
+row 'post_1' of table 'public.posts' (posts.yaml:3:5) field 'user_id': table 'users' not found
+update 0 query: internal ID `+missingIID.String()+` not found in table 'public.tags'`)
+}
+
+func TestDataValidateValid(t *testing.T) {
+	data := NewData()
+	goIID := data.AddWithID(tableTags, MapValues{
+		"tag_id": 1,
+	})
+	data.Add(tablePosts, MapValues{
+		"post_id": 1,
+		"tag_id":  ValueInternalID(tableTags, goIID.InternalID, "tag_id"),
+	})
+	assert.NilError(t, data.Validate())
+}
+
+func TestResolveValidate(t *testing.T) {
+	ctx := context.Background()
+
+	data := NewData()
+	data.Add(tableTags, MapValues{
+		"tag_id": 1,
+	})
+	data.Add(tablePosts, MapValues{
+		"post_id": 1,
+		"tag_id":  ValueRefID(tableTags, "go", "tag_id"),
+	})
+
+	var calls []string
+	_, err := Resolve(ctx, data,
+		func(ctx context.Context, resolveInfo ResolveInfo, values ValuesMutable) error {
+			calls = append(calls, resolveInfo.TableID.TableID())
+			return nil
+		},
+		WithResolveOptionProcess(testProcess{name: "p1", calls: &calls}),
+		WithResolveOptionValidate())
+	AssertIsResolveError(t, err)
+	assert.Assert(t, is.Len(calls, 0))
+
+	// references to the base data are valid.
+	baseData := NewData()
+	baseData.Add(tableTags, MapValues{
+		"_refid": SetValueRefID("go"),
+		"tag_id": 2,
+	})
+	base, err := Resolve(ctx, baseData, ResolveCheckCallback)
+	assert.NilError(t, err)
+
+	_, err = Resolve(ctx, data, ResolveCheckCallback,
+		WithResolveOptionBase(base),
+		WithResolveOptionValidate())
+	assert.NilError(t, err)
+}
@@ -124,13 +124,30 @@ func (v ValueRefIDData) UpdateQuery(keyFields []string) UpdateQuery {
 }
 
 // ValueBaseTimeAddData is a Value that calculates a date based on the Data base time.
+// The base time is converted to Location (if set) and truncated (if set). Then the time offsets and the date offsets
+// are added, in this order. If TimeOfDay is set, the date offsets are added, the time of day is set on the wall clock,
+// and the time offsets are added to it.
 type ValueBaseTimeAddData struct {
+	AddYears   int
+	AddMonths  int
 	AddDays    int
 	AddHours   int
 	AddMinutes int
 	AddSeconds int
+	Truncate   TimeTruncate   // truncates the base time before adding the offsets.
+	TimeOfDay  *time.Duration // if set, the wall clock time of day is set to this duration since midnight.
+	Location   *time.Location // if set, the base time is converted to this location.
 }
 
+// TimeTruncate is a truncation of a time value.
+type TimeTruncate int
+
+const (
+	TimeTruncateNone TimeTruncate = iota
+	TimeTruncateDay
+	TimeTruncateHour
+)
+
 // ValueBaseTimeAdd is a Value that calculates a date based on the Data base time.
 func ValueBaseTimeAdd(options ...ValueBaseTimeAddDataOption) ValueBaseTimeAddData {
 	ret := ValueBaseTimeAddData{}
@@ -161,6 +178,18 @@ func WithAddTime(hours, minutes, seconds int) ValueBaseTimeAddDataOption {
 	}
 }
 
+func WithAddYears(years int) ValueBaseTimeAddDataOption {
+	return func(v *ValueBaseTimeAddData) {
+		v.AddYears = years
+	}
+}
+
+func WithAddMonths(months int) ValueBaseTimeAddDataOption {
+	return func(v *ValueBaseTimeAddData) {
+		v.AddMonths = months
+	}
+}
+
 func WithAddDays(days int) ValueBaseTimeAddDataOption {
 	return func(v *ValueBaseTimeAddData) {
 		v.AddDays = days
@@ -185,12 +214,54 @@ func WithAddSeconds(seconds int) ValueBaseTimeAddDataOption {
 	}
 }
 
-func (v ValueBaseTimeAddData) ResolveValue(ctx context.Context, resolvedData *ResolvedData, values Values) (any, bool, error) {
-	t := resolvedData.BaseTime.Add((time.Hour * time.Duration(v.AddHours)) + (time.Minute * time.Duration(v.AddMinutes)) + (time.Second * time.Duration(v.AddSeconds)))
-	if v.AddDays > 0 {
-		t = t.AddDate(0, 0, v.AddDays)
+// WithTruncate truncates the base time before adding the offsets.
+func WithTruncate(truncate TimeTruncate) ValueBaseTimeAddDataOption {
+	return func(v *ValueBaseTimeAddData) {
+		v.Truncate = truncate
+	}
+}
+
+// WithTimeOfDay sets the time of day after adding the date offsets. The hours, minutes and seconds offsets are added
+// to it, instead of to the base time.
+func WithTimeOfDay(hour, minute, second int) ValueBaseTimeAddDataOption {
+	return func(v *ValueBaseTimeAddData) {
+		timeOfDay := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second
+		v.TimeOfDay = &timeOfDay
+	}
+}
+
+// WithLocation converts the base time to the location, so truncation and the time of day use it.
+func WithLocation(location *time.Location) ValueBaseTimeAddDataOption {
+	return func(v *ValueBaseTimeAddData) {
+		v.Location = location
 	}
-	return t, true, nil
+}
+
+func (v ValueBaseTimeAddData) ResolveValue(ctx context.Context, resolvedData *ResolvedData, values Values) (any, bool, error) {
+	t := resolvedData.BaseTime
+	if v.Location != nil {
+		t = t.In(v.Location)
+	}
+	switch v.Truncate {
+	case TimeTruncateNone:
+	case TimeTruncateDay:
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case TimeTruncateHour:
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	default:
+		return nil, false, NewResolveErrorf("unknown time truncation: %d", v.Truncate)
+	}
+	addTime := (time.Hour * time.Duration(v.AddHours)) + (time.Minute * time.Duration(v.AddMinutes)) + (time.Second * time.Duration(v.AddSeconds))
+	if v.TimeOfDay == nil {
+		t = t.Add(addTime).AddDate(v.AddYears, v.AddMonths, v.AddDays)
+		return t, true, nil
+	}
+	// the time of day is set on the wall clock, so it is the same on days with daylight saving time transitions.
+	t = t.AddDate(v.AddYears, v.AddMonths, v.AddDays)
+	tod := *v.TimeOfDay
+	t = time.Date(t.Year(), t.Month(), t.Day(), int(tod/time.Hour), int(tod%time.Hour/time.Minute),
+		int(tod%time.Minute/time.Second), int(tod%time.Second), t.Location())
+	return t.Add(addTime), true, nil
 }
 
 // ValueFormatData is a Value that formats a string based on other field's values.
@@ -3,6 +3,7 @@ package debefix
 import (
 	"context"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 )
@@ -41,3 +42,87 @@ func TestValueTemplateNoField(t *testing.T) {
 	AssertIsResolveError(t, err)
 
 }
+
+func TestValueBaseTimeAdd(t *testing.T) {
+	ctx := context.Background()
+
+	location := time.FixedZone("UTC-3", -3*60*60)
+	rd := NewResolvedData()
+	rd.BaseTime = time.Date(2024, 3, 31, 1, 20, 30, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    ValueBaseTimeAddData
+		expected time.Time
+	}{
+		{
+			name:     "add",
+			value:    ValueBaseTimeAdd(WithAddDate(1, 2, 3, 4)),
+			expected: time.Date(2024, 4, 1, 3, 23, 34, 0, time.UTC),
+		},
+		{
+			name:     "negative days",
+			value:    ValueBaseTimeAdd(WithAddDays(-30)),
+			expected: time.Date(2024, 3, 1, 1, 20, 30, 0, time.UTC),
+		},
+		{
+			name:     "years and months",
+			value:    ValueBaseTimeAdd(WithAddYears(-1), WithAddMonths(2)),
+			expected: time.Date(2023, 5, 31, 1, 20, 30, 0, time.UTC),
+		},
+		{
+			name:     "truncate day",
+			value:    ValueBaseTimeAdd(WithTruncate(TimeTruncateDay), WithAddHours(1)),
+			expected: time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "truncate hour",
+			value:    ValueBaseTimeAdd(WithTruncate(TimeTruncateHour)),
+			expected: time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "time of day",
+			value:    ValueBaseTimeAdd(WithAddDays(-1), WithTimeOfDay(9, 30, 0), WithAddMinutes(5)),
+			expected: time.Date(2024, 3, 30, 9, 35, 0, 0, time.UTC),
+		},
+		{
+			name:     "location",
+			value:    ValueBaseTimeAdd(WithLocation(location), WithTruncate(TimeTruncateDay)),
+			expected: time.Date(2024, 3, 30, 0, 0, 0, 0, location),
+		},
+	}
+
+	// daylight saving time starts on 2024-03-10 at 02:00 in New York.
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NilError(t, err)
+	tests = append(tests, []struct {
+		name     string
+		value    ValueBaseTimeAddData
+		expected time.Time
+	}{
+		{
+			name:     "time of day on daylight saving time transition",
+			value:    ValueBaseTimeAdd(WithLocation(newYork), WithTimeOfDay(15, 0, 0)),
+			expected: time.Date(2024, 3, 10, 15, 0, 0, 0, newYork),
+		},
+		{
+			name:     "time offsets before date offsets",
+			value:    ValueBaseTimeAdd(WithLocation(newYork), WithAddDate(1, 1, 0, 0)),
+			expected: time.Date(2024, 3, 11, 3, 30, 0, 0, newYork),
+		},
+	}...)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rd := rd
+			if test.value.Location == newYork {
+				rd = NewResolvedData()
+				rd.BaseTime = time.Date(2024, 3, 10, 1, 30, 0, 0, newYork)
+			}
+			value, ok, err := test.value.ResolveValue(ctx, rd, MapValues{})
+			assert.NilError(t, err)
+			assert.Assert(t, ok)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+}
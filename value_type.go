@@ -48,6 +48,7 @@ func (u ValueUUIDData) ResolveValue(ctx context.Context, resolvedData *ResolvedD
 }
 
 // ValueGenUUIDData returns a new random [uuid.UUID] value every time it is resolved.
+// It uses [Data.NewUUID], so the values are reproducible if a UUID seed was set.
 type ValueGenUUIDData struct {
 }
 
@@ -59,5 +60,5 @@ func ValueGenUUID() ValueGenUUIDData {
 var _ Value = (*ValueGenUUIDData)(nil)
 
 func (u ValueGenUUIDData) ResolveValue(ctx context.Context, resolvedData *ResolvedData, values Values) (any, bool, error) {
-	return uuid.New(), true, nil
+	return resolvedData.NewUUID(), true, nil
 }